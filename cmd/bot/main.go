@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"bugreportbot/internal/analysis"
 	"bugreportbot/internal/config"
+	"bugreportbot/internal/health"
+	"bugreportbot/internal/logging"
+	"bugreportbot/internal/metrics"
+	"bugreportbot/internal/pii"
 	"bugreportbot/internal/telegram"
+	"bugreportbot/internal/tracing"
 )
 
 func main() {
@@ -27,35 +37,174 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
+	var scrubber *pii.Scrubber
+	var logOut io.Writer = os.Stderr
+	if cfg.PIIScrub {
+		scrubber, err = newScrubber(cfg)
+		if err != nil {
+			log.Fatalf("failed to configure PII scrubbing: %v", err)
+		}
+		// Усі логи (включно з preview відповідей Ollama) проходять через scrubber.
+		logOut = pii.NewWriter(os.Stderr, scrubber)
+	}
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("failed to configure logging: %v", err)
+	}
+	// slog.SetDefault перенаправляє і стандартний log, тож записи бібліотек теж проходять через scrubber.
+	logger := logging.New(logOut, level, cfg.LogFormat)
+	slog.SetDefault(logger)
+	_ = tgbotapi.SetLogger(slog.NewLogLogger(logger.Handler(), slog.LevelDebug))
+
+	if cfg.OTLPEndpoint != "" {
+		headers, err := tracing.ParseHeaders(cfg.OTLPHeaders)
+		if err != nil {
+			fatal("failed to configure tracing", err)
+		}
+		tracer := tracing.NewTracer(tracing.NewOTLPExporter(cfg.OTLPEndpoint, cfg.ServiceName, headers))
+		tracing.SetDefault(tracer)
+		defer func() {
+			// Дочекатися експорту останніх спанів, але не затримувати зупинку надовго.
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracer.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("tracing shutdown", "err", err)
+			}
+		}()
+		slog.Info("tracing: exporting spans via OTLP", "endpoint", cfg.OTLPEndpoint, "service", cfg.ServiceName)
+	}
+
 	botAPI, err := tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
-		log.Fatalf("failed to create telegram bot api: %v", err)
+		fatal("failed to create telegram bot api", err)
 	}
 
-	log.Printf("authorized on account: @%s", botAPI.Self.UserName)
+	slog.Info("authorized on account", "username", botAPI.Self.UserName)
+
+	var ocr analysis.OCR
+	switch cfg.OCREngine {
+	case "tesseract":
+		slog.Info("OCR: tesseract", "lang", cfg.OCRLang)
+		ocr = analysis.NewTesseractOCR(cfg.TesseractPath, cfg.OCRLang)
+	case "http":
+		slog.Info("OCR: http", "url", cfg.OCRURL)
+		ocr = analysis.NewHTTPOCR(cfg.OCRURL)
+	case "", "none":
+	default:
+		slog.Warn("unknown OCR_ENGINE, OCR disabled", "engine", cfg.OCREngine)
+	}
+	if ocr != nil {
+		// Редактор і аналізатор розпізнають той самий скріншот — кеш прибирає другий виклик OCR.
+		ocr = analysis.NewCachingOCR(ocr, 64)
+	} else if cfg.RedactByDefault {
+		slog.Error("REDACT_SCREENSHOTS is on but no OCR engine is configured: screenshots will be refused until OCR_ENGINE is set or redaction is turned off")
+	}
 
 	var analyzer analysis.Analyzer
 	switch cfg.AnalysisMode {
 	case "ollama":
-		log.Printf("analysis mode: ollama (url=%s model=%s)", cfg.OllamaURL, cfg.OllamaModel)
+		slog.Info("analysis mode: ollama", "url", cfg.OllamaURL, "model", cfg.OllamaModel)
 		if err := analysis.CheckOllamaReachable(cfg.OllamaURL); err != nil {
-			log.Printf("WARNING: %v", err)
-			log.Printf("Start Ollama (open the app or run: ollama serve), then send a photo again. Until then you will get sample templates.")
+			slog.Warn("Start Ollama (open the app or run: ollama serve), then send a photo again. Until then you will get sample templates.", "err", err)
 		} else {
-			log.Printf("Ollama is reachable; AI analysis enabled.")
+			slog.Info("Ollama is reachable; AI analysis enabled.")
+		}
+		ollama := analysis.NewOllamaAnalyzer(cfg.OllamaURL, cfg.OllamaModel)
+		if cfg.AnalysisCacheSize > 0 {
+			ollama.SetCache(analysis.NewAnalysisCache(cfg.AnalysisCacheTTL, cfg.AnalysisCacheSize))
+		}
+		if ocr != nil {
+			ollama.SetOCR(ocr)
 		}
-		analyzer = analysis.NewOllamaAnalyzer(cfg.OllamaURL, cfg.OllamaModel)
+		scales, err := analysis.LoadScaleConfig(cfg.PriorityRulesFile)
+		if err != nil {
+			fatal("failed to load priority rules", err)
+		}
+		ollama.SetScaleConfig(scales)
+		ollama.SetRepairThreshold(cfg.LintMinScore)
+		analyzer = ollama
 	case "mock":
 		fallthrough
 	default:
-		log.Printf("analysis mode: mock")
+		slog.Info("analysis mode: mock")
 		analyzer = analysis.NewMockAnalyzer()
 	}
+	backend := "mock"
+	if cfg.AnalysisMode == "ollama" {
+		backend = "ollama"
+	}
+	analyzer = analysis.Instrument(analyzer, backend)
 
-	bot := telegram.NewBot(botAPI, analyzer)
+	bot, err := telegram.NewBot(botAPI, analyzer, cfg)
+	if err != nil {
+		fatal("failed to create bot", err)
+	}
+	if cfg.AnalysisMode == "ollama" && cfg.OllamaEmbedModel != "" {
+		slog.Info("duplicate detection: embeddings enabled", "model", cfg.OllamaEmbedModel)
+		bot.SetEmbedder(analysis.NewOllamaEmbedder(cfg.OllamaURL, cfg.OllamaEmbedModel))
+	}
+	if len(cfg.AdminUsers)+len(cfg.AllowedUsers)+len(cfg.AllowedChats) == 0 {
+		slog.Info("access control: off, anyone can use the bot (set ADMIN_USERS, ALLOWED_USERS or ALLOWED_CHATS)")
+	} else {
+		slog.Info("access control: on", "admins", len(cfg.AdminUsers), "allowed_users", len(cfg.AllowedUsers), "allowed_chats", len(cfg.AllowedChats))
+	}
+	bot.SetScrubber(scrubber)
+	if ocr != nil {
+		bot.SetRedactor(analysis.NewRedactor(ocr))
+	} else {
+		slog.Info("screenshot redaction unavailable: set OCR_ENGINE to enable it")
+	}
+
+	if cfg.MetricsAddr != "" {
+		checks := health.NewChecker(10 * time.Second)
+		checks.AddLiveness("telegram", bot.CheckTelegram)
+		if cfg.AnalysisMode == "ollama" {
+			checks.AddReadiness("ollama", func(context.Context) error {
+				return analysis.CheckOllamaReachable(cfg.OllamaURL)
+			})
+		}
+		go serveMonitoring(ctx, cfg.MetricsAddr, checks)
+	}
 
 	if err := bot.Run(ctx); err != nil && err != context.Canceled {
-		log.Fatalf("bot stopped with error: %v", err)
+		fatal("bot stopped with error", err)
 	}
 }
 
+// fatal пише помилку в лог і завершує процес.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+func newScrubber(cfg *config.Config) (*pii.Scrubber, error) {
+	kinds, err := pii.ParseKinds(cfg.PIIRules)
+	if err != nil {
+		return nil, err
+	}
+	custom, err := pii.ParseCustomRules(cfg.PIICustomRules)
+	if err != nil {
+		return nil, err
+	}
+	return pii.NewScrubber(kinds, custom)
+}
+
+// serveMonitoring запускає HTTP-сервер з /metrics, /healthz і /readyz до завершення ctx.
+func serveMonitoring(ctx context.Context, addr string, checks *health.Checker) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/healthz", checks.LivenessHandler())
+	mux.Handle("/readyz", checks.ReadinessHandler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	slog.Info("monitoring: /metrics, /healthz, /readyz", "addr", addr, "checks", strings.Join(checks.Names(), ", "))
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Warn("monitoring server stopped", "err", err)
+	}
+}
@@ -3,7 +3,9 @@ package analysis
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 )
 
 // TestCase описує один тест-кейс, який повертає сервіс аналізу.
@@ -15,16 +17,122 @@ type TestCase struct {
 	Steps         []string
 	Expected      string
 	Actual        string
-	// Priority — бізнес-пріоритет (наприклад, High / Medium / Low).
-	Priority string
-	// Severity — рівень впливу (наприклад, Critical / Major / Minor).
-	Severity string
+	// Priority — бізнес-пріоритет (High / Medium / Low); див. NormalizeScales.
+	Priority Priority
+	// Severity — рівень впливу (Critical / Major / Minor / Trivial).
+	Severity Severity
+	// Category — відтворення бага чи додатковий кейс режиму /mode ("" — як reproduction).
+	Category Category
+	// Region — де на скріншоті видно проблему (nil для текстових звітів або якщо модель не вказала).
+	Region *Region
+}
+
+// Environment описує середовище, в якому відтворюється баг.
+type Environment struct {
+	OS         string
+	Device     string // браузер або пристрій (Chrome 126, Pixel 7)
+	AppVersion string
+}
+
+// IsEmpty повідомляє, що про середовище нічого не відомо.
+func (e *Environment) IsEmpty() bool {
+	return e == nil || (e.OS == "" && e.Device == "" && e.AppVersion == "")
 }
 
 // BugAnalysis містить агреговану інформацію про баг та пов'язані тест-кейси.
 type BugAnalysis struct {
 	BugTitle  string
 	TestCases []TestCase
+
+	// Summary — 1–2 речення про те, що зламано і на що це впливає.
+	Summary string
+	// Component — функціональна частина продукту (Checkout, Login form).
+	Component string
+	// Environment — середовище відтворення (nil, якщо невідоме).
+	Environment *Environment
+	// Reproducibility — як часто відтворюється: Always / Sometimes / Once / Unknown.
+	Reproducibility string
+	// Tags — короткі мітки для пошуку й групування (ui, payments, crash).
+	Tags []string
+	// RootCauseHypothesis — припущення моделі про причину; не перевірений факт.
+	RootCauseHypothesis string
+
+	// Notes — примітки для користувача щодо якості результату (наприклад, підписи, яких OCR не знайшов на скріншоті).
+	Notes []string
+
+	// Cached — результат узято з кешу аналізу, модель повторно не викликалась.
+	Cached bool
+}
+
+// Clone повертає глибоку копію, щоб зміни (наприклад, перенумерація ID) не зачіпали оригінал.
+func (a *BugAnalysis) Clone() *BugAnalysis {
+	if a == nil {
+		return nil
+	}
+	out := *a
+	out.Notes = append([]string(nil), a.Notes...)
+	out.Tags = append([]string(nil), a.Tags...)
+	if a.Environment != nil {
+		env := *a.Environment
+		out.Environment = &env
+	}
+	out.TestCases = make([]TestCase, len(a.TestCases))
+	for i, tc := range a.TestCases {
+		tc.Preconditions = append([]string(nil), tc.Preconditions...)
+		tc.Steps = append([]string(nil), tc.Steps...)
+		if tc.Region != nil {
+			r := *tc.Region
+			tc.Region = &r
+		}
+		out.TestCases[i] = tc
+	}
+	return &out
+}
+
+// caseRef — як послатися на тест-кейс у примітці. За назвою, а не за номером чи ID: примітки пишуться під час
+// аналізу, а бот потім об'єднує результати, прибирає дублікати і присвоює стабільні ID, тож номер уже не збігся б.
+func caseRef(tc TestCase) string {
+	if t := strings.Join(strings.Fields(tc.Title), " "); t != "" {
+		return fmt.Sprintf("%q", t)
+	}
+	if len(tc.Steps) > 0 {
+		return fmt.Sprintf("starting with %q", strings.Join(strings.Fields(tc.Steps[0]), " "))
+	}
+	return "without a title"
+}
+
+// MapText застосовує fn до всіх текстових полів аналізу (наприклад, щоб замаскувати персональні дані).
+func (a *BugAnalysis) MapText(fn func(string) string) {
+	if a == nil {
+		return
+	}
+	a.BugTitle = fn(a.BugTitle)
+	a.Summary = fn(a.Summary)
+	a.Component = fn(a.Component)
+	a.RootCauseHypothesis = fn(a.RootCauseHypothesis)
+	for i, t := range a.Tags {
+		a.Tags[i] = fn(t)
+	}
+	if a.Environment != nil {
+		a.Environment.OS = fn(a.Environment.OS)
+		a.Environment.Device = fn(a.Environment.Device)
+		a.Environment.AppVersion = fn(a.Environment.AppVersion)
+	}
+	for i, n := range a.Notes {
+		a.Notes[i] = fn(n)
+	}
+	for i := range a.TestCases {
+		tc := &a.TestCases[i]
+		tc.Title = fn(tc.Title)
+		tc.Expected = fn(tc.Expected)
+		tc.Actual = fn(tc.Actual)
+		for j, p := range tc.Preconditions {
+			tc.Preconditions[j] = fn(p)
+		}
+		for j, st := range tc.Steps {
+			tc.Steps[j] = fn(st)
+		}
+	}
 }
 
 // Analyzer описує інтерфейс сервісу аналізу.
@@ -44,7 +152,11 @@ func NewMockAnalyzer() Analyzer {
 // Analyze ігнорує вхідне зображення та повертає статичний набір тест-кейсів.
 func (m *MockAnalyzer) Analyze(_ context.Context, _ []byte) (*BugAnalysis, error) {
 	return &BugAnalysis{
-		BugTitle: "Submit button is visually truncated on the login screen",
+		BugTitle:        "Submit button is visually truncated on the login screen",
+		Summary:         "The Submit button on the login screen is cut off, so users cannot reliably sign in.",
+		Component:       "Login",
+		Reproducibility: "Always",
+		Tags:            []string{"ui", "layout", "login"},
 		TestCases: []TestCase{
 			{
 				ID:    "TC-001",
@@ -66,19 +178,20 @@ func (m *MockAnalyzer) Analyze(_ context.Context, _ []byte) (*BugAnalysis, error
 }
 
 // FallbackTemplate повертає шаблон тест-кейсу, коли основний аналізатор недоступний (для фото).
+// ID у шаблона немає: номер з лічильника проєкту він отримає, лише коли його відредагують у справжній тест-кейс.
 func FallbackTemplate() *BugAnalysis {
+	fallbacksTotal.Inc("template")
 	return &BugAnalysis{
 		BugTitle: "Sample bug / test case template",
 		TestCases: []TestCase{
 			{
-				ID:            "TC-001",
 				Title:         "Verify the reported issue on the screenshot / description",
 				Preconditions: []string{"Application is open", "User has reproduced the bug"},
 				Steps:         []string{"Open the affected screen", "Perform the steps that trigger the bug", "Observe the result"},
-				Expected:     "Expected correct behaviour according to requirements",
-				Actual:       "Actual behaviour (describe what you see)",
-				Priority:     "Medium",
-				Severity:     "Major",
+				Expected:      "Expected correct behaviour according to requirements",
+				Actual:        "Actual behaviour (describe what you see)",
+				Priority:      "Medium",
+				Severity:      "Major",
 			},
 		},
 	}
@@ -91,6 +204,7 @@ func FallbackFromUserDescription(description string) *BugAnalysis {
 	if desc == "" {
 		return FallbackTemplate()
 	}
+	fallbacksTotal.Inc("description")
 	title := desc
 	if len(title) > 120 {
 		title = title[:117] + "..."
@@ -121,17 +235,21 @@ func FallbackFromUserDescription(description string) *BugAnalysis {
 // AnalyzeText ігнорує текстовий опис і повертає той самий статичний набір тест-кейсів.
 func (m *MockAnalyzer) AnalyzeText(_ context.Context, _ string) (*BugAnalysis, error) {
 	return &BugAnalysis{
-		BugTitle: "Submit button is visually truncated on the login screen",
+		BugTitle:        "Submit button is visually truncated on the login screen",
+		Summary:         "The Submit button on the login screen is cut off, so users cannot reliably sign in.",
+		Component:       "Login",
+		Reproducibility: "Always",
+		Tags:            []string{"ui", "layout", "login"},
 		TestCases: []TestCase{
 			{
-				ID:    "TC-001",
-				Title: "Verify that the Submit button is fully visible on the login screen",
+				ID:            "TC-001",
+				Title:         "Verify that the Submit button is fully visible on the login screen",
 				Preconditions: []string{"User is on the login screen"},
-				Steps: []string{"Open the login screen", "Wait until all fields are fully loaded"},
-				Expected: "The Submit button is fully visible and clickable",
-				Actual:   "The Submit button is partially cut off and not fully visible",
-				Priority: "High",
-				Severity: "Major",
+				Steps:         []string{"Open the login screen", "Wait until all fields are fully loaded"},
+				Expected:      "The Submit button is fully visible and clickable",
+				Actual:        "The Submit button is partially cut off and not fully visible",
+				Priority:      "High",
+				Severity:      "Major",
 			},
 		},
 	}, nil
@@ -153,13 +271,193 @@ func FormatBugAnalysis(a *BugAnalysis) string {
 	b.WriteString(a.BugTitle)
 	b.WriteString("\n\n")
 
+	if a.Summary != "" {
+		b.WriteString(a.Summary)
+		b.WriteString("\n\n")
+	}
+	if a.Component != "" || a.Reproducibility != "" || len(a.Tags) > 0 {
+		writeField(&b, "Component", a.Component)
+		writeField(&b, "Reproducibility", a.Reproducibility)
+		writeField(&b, "Tags", strings.Join(a.Tags, ", "))
+		b.WriteString("\n")
+	}
+
+	if !a.Environment.IsEmpty() {
+		b.WriteString("Environment:\n")
+		writeField(&b, "OS", a.Environment.OS)
+		writeField(&b, "Browser / device", a.Environment.Device)
+		writeField(&b, "App version", a.Environment.AppVersion)
+		b.WriteString("\n")
+	}
+
 	for i, tc := range a.TestCases {
 		b.WriteString(formatTestCase(i+1, &tc))
 	}
 
+	if a.RootCauseHypothesis != "" {
+		b.WriteString("────────────────────\n")
+		b.WriteString("Root cause hypothesis (unverified):\n")
+		b.WriteString(a.RootCauseHypothesis)
+		b.WriteString("\n")
+	}
+
+	if len(a.Notes) > 0 {
+		b.WriteString("────────────────────\n")
+		b.WriteString("Notes:\n")
+		for _, n := range a.Notes {
+			b.WriteString("- ")
+			b.WriteString(n)
+			b.WriteString("\n")
+		}
+	}
+
 	return b.String()
 }
 
+// writeField пише рядок "- name: value", якщо значення не порожнє.
+func writeField(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	b.WriteString("- ")
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(value)
+	b.WriteString("\n")
+}
+
+// MergeAnalyses дописує в dst тест-кейси з extra, яких там ще немає (за схожістю тексту), та його примітки.
+// Порожні поля dst (опис, компонент, середовище тощо) заповнюються з extra, мітки об'єднуються.
+func MergeAnalyses(dst, extra *BugAnalysis) {
+	if dst == nil || extra == nil {
+		return
+	}
+	fill := func(dst *string, v string) {
+		if *dst == "" {
+			*dst = v
+		}
+	}
+	fill(&dst.Summary, extra.Summary)
+	fill(&dst.Component, extra.Component)
+	fill(&dst.Reproducibility, extra.Reproducibility)
+	fill(&dst.RootCauseHypothesis, extra.RootCauseHypothesis)
+	dst.Environment = MergeEnvironment(dst.Environment, extra.Environment)
+	dst.Tags = MergeTags(dst.Tags, extra.Tags)
+	for _, tc := range extra.TestCases {
+		if !containsSimilarTestCase(dst.TestCases, tc) {
+			dst.TestCases = append(dst.TestCases, tc)
+		}
+	}
+	dst.Notes = append(dst.Notes, extra.Notes...)
+}
+
+// NormalizeReproducibility зводить довільну відповідь про частоту відтворення до Always / Sometimes / Once / Unknown.
+// Порожній рядок лишається порожнім. Слова порівнюються цілими ("зараз" — не "раз"), частки на кшталт "1 in 100"
+// чи "30%" — за значенням, а "Always" перевіряється раніше за "Once", щоб "кожного разу" не стало одноразовим.
+func NormalizeReproducibility(v string) string {
+	l := strings.ToLower(strings.TrimSpace(v))
+	if l == "" {
+		return ""
+	}
+	if m := reproRatioRe.FindStringSubmatch(l); m != nil {
+		if m[1] == m[2] {
+			return "Always"
+		}
+		return "Sometimes"
+	}
+	if m := reproPercentRe.FindStringSubmatch(l); m != nil {
+		if m[1] == "100" {
+			return "Always"
+		}
+		return "Sometimes"
+	}
+	words := " " + strings.Join(strings.FieldsFunc(l, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }), " ") + " "
+	switch {
+	case reproSometimesRe.MatchString(words):
+		return "Sometimes"
+	case reproAlwaysRe.MatchString(words):
+		return "Always"
+	case reproOnceRe.MatchString(words):
+		return "Once"
+	default:
+		return "Unknown"
+	}
+}
+
+var (
+	// reproRatioRe — "1 in 100", "3 of 10", "2 з 5", "1 из 3", "5/5".
+	reproRatioRe = regexp.MustCompile(`(\d+)\s*(?:in|of|out of|з|із|из|/)\s*(\d+)`)
+	// reproPercentRe — "100%", "30 %".
+	reproPercentRe = regexp.MustCompile(`(\d+)\s*%`)
+
+	// "Sometimes" перевіряється першим, бо містить заперечення "not always" / "не завжди".
+	reproSometimesRe = phrasesRe("sometimes", "intermittent*", "flaky", "random*", "occasional*", "not always", "more than once", "from time to time",
+		"іноді", "інколи", "часом", "періодично", "не завжди", "кілька разів", "декілька разів",
+		"иногда", "периодически", "не всегда", "несколько раз")
+	reproAlwaysRe = phrasesRe("always", "every time", "everytime", "each time", "consistent*", "reliably", "every",
+		"завжди", "кожн*", "кожен", "щоразу", "постійно",
+		"всегда", "кажд*", "постоянно")
+	reproOnceRe = phrasesRe("once", "one time", "single", "раз", "одного разу", "одноразово", "однократно", "единожды", "одного раза")
+)
+
+// phrasesRe будує вираз, що знаходить одну з фраз цілими словами в тексті виду " слово слово ";
+// "*" у кінці слова — будь-яке закінчення (кожного, кожен, кожний).
+func phrasesRe(phrases ...string) *regexp.Regexp {
+	alts := make([]string, len(phrases))
+	for i, p := range phrases {
+		words := strings.Fields(p)
+		for j, w := range words {
+			if strings.HasSuffix(w, "*") {
+				words[j] = regexp.QuoteMeta(strings.TrimSuffix(w, "*")) + `\S*`
+			} else {
+				words[j] = regexp.QuoteMeta(w)
+			}
+		}
+		alts[i] = strings.Join(words, " ")
+	}
+	return regexp.MustCompile(" (?:" + strings.Join(alts, "|") + ") ")
+}
+
+// MergeEnvironment повертає середовище primary, доповнене полями fallback там, де primary порожній.
+func MergeEnvironment(primary, fallback *Environment) *Environment {
+	if fallback.IsEmpty() {
+		return primary
+	}
+	if primary.IsEmpty() {
+		env := *fallback
+		return &env
+	}
+	env := *primary
+	if env.OS == "" {
+		env.OS = fallback.OS
+	}
+	if env.Device == "" {
+		env.Device = fallback.Device
+	}
+	if env.AppVersion == "" {
+		env.AppVersion = fallback.AppVersion
+	}
+	return &env
+}
+
+// MergeTags об'єднує мітки без урахування регістру, зберігаючи порядок першої появи.
+func MergeTags(lists ...[]string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, t := range list {
+			t = strings.TrimSpace(t)
+			key := strings.ToLower(t)
+			if t == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
 // deduplicateTestCases прибирає дублікати тест-кейсів за ключем (Title, Expected, Actual).
 func deduplicateTestCases(in []TestCase) []TestCase {
 	if len(in) <= 1 {
@@ -189,7 +487,15 @@ func formatTestCase(idx int, tc *TestCase) string {
 	var b strings.Builder
 
 	b.WriteString("────────────────────\n")
-	b.WriteString(fmt.Sprintf("Test case %s #%d\n", tc.ID, idx))
+	label := "Test case"
+	if tc.ID != "" {
+		label += " " + tc.ID
+	}
+	if tc.Category != "" && tc.Category != CategoryReproduction {
+		b.WriteString(fmt.Sprintf("%s #%d (%s)\n", label, idx, tc.Category))
+	} else {
+		b.WriteString(fmt.Sprintf("%s #%d\n", label, idx))
+	}
 	if tc.Title != "" {
 		b.WriteString(tc.Title)
 		b.WriteString("\n")
@@ -227,12 +533,12 @@ func formatTestCase(idx int, tc *TestCase) string {
 		b.WriteString("\nPriority / Severity:\n")
 		if tc.Priority != "" {
 			b.WriteString("- Priority: ")
-			b.WriteString(tc.Priority)
+			b.WriteString(string(tc.Priority))
 			b.WriteString("\n")
 		}
 		if tc.Severity != "" {
 			b.WriteString("- Severity: ")
-			b.WriteString(tc.Severity)
+			b.WriteString(string(tc.Severity))
 			b.WriteString("\n")
 		}
 	}
@@ -240,4 +546,3 @@ func formatTestCase(idx int, tc *TestCase) string {
 	b.WriteString("\n")
 	return b.String()
 }
-
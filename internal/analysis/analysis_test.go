@@ -0,0 +1,42 @@
+package analysis
+
+import "testing"
+
+func TestNormalizeReproducibility(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"  ", ""},
+		{"Always", "Always"},
+		{"every time I tap Save", "Always"},
+		{"Consistently", "Always"},
+		{"100%", "Always"},
+		{"5/5 attempts", "Always"},
+		{"завжди", "Always"},
+		{"кожного разу", "Always"},
+		{"Кожен раз після оновлення", "Always"},
+		{"щоразу", "Always"},
+		{"каждый раз", "Always"},
+		{"Sometimes", "Sometimes"},
+		{"flaky, maybe 30%", "Sometimes"},
+		{"1 in 100", "Sometimes"},
+		{"about 3 of 10 runs", "Sometimes"},
+		{"not always", "Sometimes"},
+		{"не завжди", "Sometimes"},
+		{"іноді", "Sometimes"},
+		{"happened more than once", "Sometimes"},
+		{"Once", "Once"},
+		{"only one time", "Once"},
+		{"лише один раз", "Once"},
+		{"одного разу", "Once"},
+		{"зараз не знаю", "Unknown"},
+		{"raz", "Unknown"},
+		{"don't know", "Unknown"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeReproducibility(tt.in); got != tt.want {
+			t.Errorf("NormalizeReproducibility(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Region — прямокутник проблемного елемента на скріншоті у відносних координатах (0..1),
+// тому він однаково накладається і на зменшене для моделі, і на оригінальне зображення.
+type Region struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// normalize обрізає регіон до меж зображення; повертає nil, якщо від нього нічого не лишилось.
+func (r *Region) normalize() *Region {
+	if r == nil {
+		return nil
+	}
+	x0, y0 := clamp01(r.X), clamp01(r.Y)
+	x1, y1 := clamp01(r.X+r.Width), clamp01(r.Y+r.Height)
+	if x1-x0 <= 0 || y1-y0 <= 0 {
+		return nil
+	}
+	return &Region{X: x0, Y: y0, Width: x1 - x0, Height: y1 - y0}
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// annotationColors — кольори рамок по колу, щоб сусідні регіони розрізнялись.
+var annotationColors = []color.RGBA{
+	{R: 230, G: 25, B: 75, A: 255},
+	{R: 0, G: 130, B: 200, A: 255},
+	{R: 245, G: 130, B: 48, A: 255},
+	{R: 60, G: 180, B: 75, A: 255},
+	{R: 145, G: 30, B: 180, A: 255},
+}
+
+// HasRegions повідомляє, чи є в аналізі хоча б один тест-кейс з позначеним регіоном.
+func HasRegions(a *BugAnalysis) bool {
+	if a == nil {
+		return false
+	}
+	for _, tc := range a.TestCases {
+		if tc.Region != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotateScreenshot малює на оригінальному скріншоті пронумеровані рамки навколо регіонів тест-кейсів.
+// Номер рамки — порядковий номер тест-кейсу у відповіді бота (#1, #2, ...). Повертає PNG.
+func AnnotateScreenshot(raw []byte, cases []TestCase) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	longer := w
+	if h > longer {
+		longer = h
+	}
+	thickness := longer / 300
+	if thickness < 2 {
+		thickness = 2
+	}
+	labelScale := longer / 500
+	if labelScale < 1 {
+		labelScale = 1
+	}
+
+	for i, tc := range cases {
+		r := tc.Region.normalize()
+		if r == nil {
+			continue
+		}
+		c := annotationColors[i%len(annotationColors)]
+		rect := image.Rect(
+			int(r.X*float64(w)), int(r.Y*float64(h)),
+			int((r.X+r.Width)*float64(w)), int((r.Y+r.Height)*float64(h)),
+		)
+		drawFrame(dst, rect, thickness, c)
+		drawLabel(dst, rect.Min, strconv.Itoa(i+1), labelScale, c)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func drawFrame(dst *image.RGBA, r image.Rectangle, t int, c color.RGBA) {
+	u := image.NewUniform(c)
+	for _, side := range []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+t),
+		image.Rect(r.Min.X, r.Max.Y-t, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+t, r.Max.Y),
+		image.Rect(r.Max.X-t, r.Min.Y, r.Max.X, r.Max.Y),
+	} {
+		draw.Draw(dst, side.Intersect(dst.Bounds()), u, image.Point{}, draw.Src)
+	}
+}
+
+// drawLabel малює номер на кольоровій плашці у верхньому лівому куті рамки.
+// Вбудований шрифт дрібний, тому плашка рендериться в малому масштабі й збільшується в scale разів.
+func drawLabel(dst *image.RGBA, at image.Point, text string, scale int, c color.RGBA) {
+	face := basicfont.Face7x13
+	const pad = 2
+	tw := font.MeasureString(face, text).Ceil()
+	small := image.NewRGBA(image.Rect(0, 0, tw+2*pad, face.Height+2*pad))
+	draw.Draw(small, small.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	d := &font.Drawer{
+		Dst:  small,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(pad, pad+face.Ascent),
+	}
+	d.DrawString(text)
+
+	target := image.Rect(at.X, at.Y, at.X+small.Bounds().Dx()*scale, at.Y+small.Bounds().Dy()*scale)
+	// Якщо плашка вилазить за межі — зсуваємо її всередину зображення.
+	if off := target.Max.X - dst.Bounds().Max.X; off > 0 {
+		target = target.Sub(image.Pt(off, 0))
+	}
+	if off := target.Max.Y - dst.Bounds().Max.Y; off > 0 {
+		target = target.Sub(image.Pt(0, off))
+	}
+	draw.NearestNeighbor.Scale(dst, target, small, small.Bounds(), draw.Src, nil)
+}
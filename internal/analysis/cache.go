@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheKey визначає результат аналізу: той самий скріншот, та сама модель і та сама версія промпту.
+type CacheKey struct {
+	ImageHash     uint64
+	Model         string
+	PromptVersion string
+}
+
+type cacheEntry struct {
+	key      CacheKey
+	result   *BugAnalysis
+	storedAt time.Time
+}
+
+// AnalysisCache — LRU-кеш результатів аналізу з TTL, щоб повторно надісланий скріншот
+// не запускав ще один багатохвилинний виклик моделі.
+type AnalysisCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // найсвіжіші спереду
+	entries    map[CacheKey]*list.Element
+	now        func() time.Time
+}
+
+// NewAnalysisCache створює кеш на maxEntries записів; ttl <= 0 — записи не застарівають.
+func NewAnalysisCache(ttl time.Duration, maxEntries int) *AnalysisCache {
+	return &AnalysisCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[CacheKey]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get повертає копію збереженого результату (з Cached=true) або nil.
+func (c *AnalysisCache) Get(key CacheKey) *BugAnalysis {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	if c.ttl > 0 && c.now().Sub(e.storedAt) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil
+	}
+	c.order.MoveToFront(el)
+	out := e.result.Clone()
+	out.Cached = true
+	return out
+}
+
+// Put зберігає копію результату, витісняючи найдавніше використаний запис при переповненні.
+func (c *AnalysisCache) Put(key CacheKey, res *BugAnalysis) {
+	if res == nil || c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = &cacheEntry{key: key, result: res.Clone(), storedAt: c.now()}
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, result: res.Clone(), storedAt: c.now()})
+	for c.order.Len() > c.maxEntries {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*cacheEntry).key)
+	}
+}
+
+type forceRefreshKey struct{}
+
+// WithForceRefresh позначає запит як примусовий повторний аналіз (кеш ігнорується, але оновлюється).
+func WithForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+// ForceRefresh повідомляє, чи просили проігнорувати кеш для цього запиту.
+func ForceRefresh(ctx context.Context) bool {
+	v, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return v
+}
@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalysisCache(t *testing.T) {
+	key := func(hash uint64) CacheKey { return CacheKey{ImageHash: hash, Model: "llava", PromptVersion: "v1"} }
+	result := func(title string) *BugAnalysis { return &BugAnalysis{BugTitle: title} }
+
+	type op struct {
+		advance time.Duration
+		put     *BugAnalysis // nil — Get
+		key     CacheKey
+		want    string // назва результату, який має повернути Get; "" — промах
+	}
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		maxEntries int
+		ops        []op
+	}{
+		{
+			name: "hit and miss", ttl: time.Hour, maxEntries: 2,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{key: key(1), want: "a"},
+				{key: key(2)},
+				{key: CacheKey{ImageHash: 1, Model: "llava", PromptVersion: "v2"}},
+				{key: CacheKey{ImageHash: 1, Model: "bakllava", PromptVersion: "v1"}},
+			},
+		},
+		{
+			name: "put replaces", ttl: time.Hour, maxEntries: 2,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{put: result("b"), key: key(1)},
+				{key: key(1), want: "b"},
+			},
+		},
+		{
+			name: "ttl expires", ttl: time.Hour, maxEntries: 2,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{advance: time.Hour, key: key(1), want: "a"},
+				{advance: time.Minute, key: key(1)},
+			},
+		},
+		{
+			name: "zero ttl never expires", ttl: 0, maxEntries: 2,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{advance: 1000 * time.Hour, key: key(1), want: "a"},
+			},
+		},
+		{
+			name: "evicts least recently used", ttl: time.Hour, maxEntries: 2,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{put: result("b"), key: key(2)},
+				{key: key(1), want: "a"}, // 1 стає найсвіжішим
+				{put: result("c"), key: key(3)},
+				{key: key(2)},
+				{key: key(1), want: "a"},
+				{key: key(3), want: "c"},
+			},
+		},
+		{
+			name: "disabled", ttl: time.Hour, maxEntries: 0,
+			ops: []op{
+				{put: result("a"), key: key(1)},
+				{key: key(1)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAnalysisCache(tt.ttl, tt.maxEntries)
+			now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+			c.now = func() time.Time { return now }
+			for i, o := range tt.ops {
+				now = now.Add(o.advance)
+				if o.put != nil {
+					c.Put(o.key, o.put)
+					continue
+				}
+				got := c.Get(o.key)
+				switch {
+				case o.want == "" && got != nil:
+					t.Fatalf("op %d: Get = %q, want miss", i, got.BugTitle)
+				case o.want != "" && got == nil:
+					t.Fatalf("op %d: Get missed, want %q", i, o.want)
+				case got != nil && (got.BugTitle != o.want || !got.Cached):
+					t.Fatalf("op %d: Get = %q (cached %v), want %q from cache", i, got.BugTitle, got.Cached, o.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalysisCacheReturnsCopies(t *testing.T) {
+	c := NewAnalysisCache(time.Hour, 1)
+	key := CacheKey{ImageHash: 1}
+	orig := &BugAnalysis{
+		BugTitle:    "Login fails",
+		Tags:        []string{"auth"},
+		Environment: &Environment{OS: "Android 14"},
+		TestCases: []TestCase{{
+			ID:     "TC-001",
+			Steps:  []string{"Open the app"},
+			Region: &Region{X: 0.1, Y: 0.2, Width: 0.3, Height: 0.4},
+		}},
+	}
+	c.Put(key, orig)
+	// Зміни оригіналу після Put не потрапляють у кеш.
+	orig.TestCases[0].Steps[0] = "changed"
+	orig.Tags[0] = "changed"
+
+	first := c.Get(key)
+	if first.TestCases[0].Steps[0] != "Open the app" || first.Tags[0] != "auth" {
+		t.Fatalf("cache shares slices with the stored result: %+v", first)
+	}
+	// Зміни отриманої копії (перенумерація ID, маскування тексту) не псують кеш.
+	first.TestCases[0].ID = "TC-042"
+	first.TestCases[0].Region.X = 0.9
+	first.Environment.OS = "changed"
+	first.Tags = append(first.Tags, "extra")
+
+	second := c.Get(key)
+	tc := second.TestCases[0]
+	if tc.ID != "TC-001" || tc.Region.X != 0.1 || second.Environment.OS != "Android 14" || len(second.Tags) != 1 {
+		t.Errorf("cached result changed through a returned copy: %+v, %+v", second, tc)
+	}
+	if orig.Cached {
+		t.Error("Get marked the original as cached")
+	}
+}
@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"bugreportbot/internal/tracing"
+)
+
+// Embedder перетворює текст у вектор для семантичного порівняння.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// OllamaEmbedder отримує ембединги з локального Ollama (/api/embeddings), наприклад моделлю nomic-embed-text.
+type OllamaEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+func NewOllamaEmbedder(baseURL, model string) *OllamaEmbedder {
+	return &OllamaEmbedder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type ollamaEmbeddingsRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbeddingsResponse struct {
+	Embedding []float64 `json:"embedding"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, text string) (_ []float64, err error) {
+	start := time.Now()
+	var span *tracing.Span
+	defer func() {
+		observeOllama("embeddings", start, err)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(&ollamaEmbeddingsRequest{Model: e.model, Prompt: text}); err != nil {
+		return nil, fmt.Errorf("encode ollama embeddings request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embeddings", &buf)
+	if err != nil {
+		return nil, fmt.Errorf("create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	span = startOllamaSpan(ctx, req, "embeddings", e.model)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call ollama (embeddings): %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ollama embeddings http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var out ollamaEmbeddingsResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decode ollama embeddings response: %w", err)
+	}
+	if out.Error != "" {
+		return nil, fmt.Errorf("ollama embeddings error: %s", out.Error)
+	}
+	if len(out.Embedding) == 0 {
+		return nil, fmt.Errorf("ollama returned an empty embedding")
+	}
+	return out.Embedding, nil
+}
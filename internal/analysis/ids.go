@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"bugreportbot/internal/storage"
+)
+
+// DefaultIDPrefix — префікс ID тест-кейсів, якщо для проєкту не задано власний.
+const DefaultIDPrefix = "TC"
+
+// IDAllocator видає послідовні номери тест-кейсів окремо для кожного проєкту/чату
+// і зберігає лічильники на диск, щоб ID не повторювались між звітами.
+// Порожній path — лічильники живуть лише в пам'яті.
+type IDAllocator struct {
+	mu       sync.Mutex
+	path     string
+	counters map[string]int
+}
+
+// NewIDAllocator створює аллокатор і підвантажує збережені лічильники з path.
+func NewIDAllocator(path string) (*IDAllocator, error) {
+	a := &IDAllocator{
+		path:     path,
+		counters: make(map[string]int),
+	}
+	if path != "" {
+		if err := storage.LoadJSON(path, &a.counters); err != nil {
+			return nil, fmt.Errorf("load test case id counters: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// FormatTestCaseID будує ID виду "TC-042", "LOGIN-TC-042" або "LOGIN-TC-RAW-042" (для fallback-кейсів).
+func FormatTestCaseID(prefix string, n int, raw bool) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "-")
+	if prefix == "" {
+		prefix = DefaultIDPrefix
+	}
+	if raw {
+		return fmt.Sprintf("%s-RAW-%03d", prefix, n)
+	}
+	return fmt.Sprintf("%s-%03d", prefix, n)
+}
+
+// isRawID повертає true для ID fallback-кейсів (TC-RAW-001 тощо), щоб зберегти позначку при перенумерації.
+func isRawID(id string) bool {
+	return strings.Contains(strings.ToUpper(id), "-RAW-")
+}
+
+// Assign перенумеровує TestCase.ID у результаті наступними вільними номерами проєкту.
+// Дублікати прибираються до нумерації, щоб у послідовності не було пропусків.
+func (a *IDAllocator) Assign(project, prefix string, res *BugAnalysis) error {
+	return a.AssignStable(project, prefix, nil, res)
+}
+
+// AssignStable перенумеровує res, повторно використовуючи ID з prev за позицією
+// (щоб після Edit кейси зберігали свої ID); нові номери видаються лише для зайвих кейсів.
+func (a *IDAllocator) AssignStable(project, prefix string, prev, res *BugAnalysis) error {
+	if res == nil {
+		return nil
+	}
+	res.TestCases = deduplicateTestCases(res.TestCases)
+
+	var reuse []string
+	if prev != nil {
+		for _, tc := range prev.TestCases {
+			if tc.ID != "" {
+				reuse = append(reuse, tc.ID)
+			}
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	allocated := false
+	for i := range res.TestCases {
+		if i < len(reuse) {
+			res.TestCases[i].ID = reuse[i]
+			continue
+		}
+		a.counters[project]++
+		res.TestCases[i].ID = FormatTestCaseID(prefix, a.counters[project], isRawID(res.TestCases[i].ID))
+		allocated = true
+	}
+	if !allocated || a.path == "" {
+		return nil
+	}
+	if err := storage.SaveJSON(a.path, a.counters); err != nil {
+		return fmt.Errorf("save test case id counters: %w", err)
+	}
+	return nil
+}
@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"image"
 	"image/jpeg"
-	_ "image/png"
+	"image/png"
+	"math"
 
 	"golang.org/x/image/draw"
 )
@@ -13,18 +14,47 @@ import (
 const maxSize = 1024
 const jpegQuality = 85
 
-// prepareImageForOllama зменшує та стискає зображення для Ollama, щоб уникнути таймаутів.
-// Повертає JPEG-байти (max 1024px по довшій стороні, якість 85).
-func prepareImageForOllama(raw []byte) ([]byte, error) {
+const (
+	// tileAspectThreshold — з якого співвідношення сторін скріншот вважається "довгим" і ріжеться на частини.
+	tileAspectThreshold = 2.2
+	// tileAspect — співвідношення довгої сторони частини до короткої.
+	tileAspect = 1.4
+	// tileOverlap — частка перекриття сусідніх частин, щоб елемент на межі потрапив цілим хоча б в одну.
+	tileOverlap = 0.15
+	maxTiles    = 6
+)
+
+func decodeImage(raw []byte) (image.Image, error) {
 	img, _, err := image.Decode(bytes.NewReader(raw))
 	if err != nil {
 		return nil, fmt.Errorf("decode image: %w", err)
 	}
+	return img, nil
+}
+
+// prepareImageForOllama зменшує та стискає зображення для Ollama, щоб уникнути таймаутів.
+// Повертає JPEG-байти (max 1024px по довшій стороні, якість 85) і перцептивний хеш
+// підготовленого зображення (ключ кешу результатів аналізу).
+func prepareImageForOllama(raw []byte) ([]byte, uint64, error) {
+	img, err := decodeImage(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	return prepareDecoded(img)
+}
+
+// prepareImageTile вирізає частину зображення (tile у відносних координатах) і готує її як prepareImageForOllama.
+func prepareImageTile(img image.Image, tile Region) ([]byte, error) {
+	sub := subImage(img, tile)
+	out, _, err := prepareDecoded(sub)
+	return out, err
+}
 
+func prepareDecoded(img image.Image) ([]byte, uint64, error) {
 	bounds := img.Bounds()
 	w, h := bounds.Dx(), bounds.Dy()
 	if w <= 0 || h <= 0 {
-		return nil, fmt.Errorf("invalid image size")
+		return nil, 0, fmt.Errorf("invalid image size")
 	}
 
 	// Зменшити, якщо більше maxSize по довшій стороні.
@@ -50,7 +80,111 @@ func prepareImageForOllama(raw []byte) ([]byte, error) {
 
 	var out bytes.Buffer
 	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
-		return nil, fmt.Errorf("encode jpeg: %w", err)
+		return nil, 0, fmt.Errorf("encode jpeg: %w", err)
+	}
+	return out.Bytes(), perceptualHashImage(dst), nil
+}
+
+// subImage повертає частину зображення за відносними координатами r.
+func subImage(img image.Image, r Region) image.Image {
+	b := img.Bounds()
+	rect := image.Rect(
+		b.Min.X+int(r.X*float64(b.Dx())), b.Min.Y+int(r.Y*float64(b.Dy())),
+		b.Min.X+int(math.Ceil((r.X+r.Width)*float64(b.Dx()))), b.Min.Y+int(math.Ceil((r.Y+r.Height)*float64(b.Dy()))),
+	).Intersect(b)
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// planImageTiles вирішує, чи треба різати скріншот на частини, і повертає їх у відносних координатах
+// (nil — зображення звичайних пропорцій або його не вдалося прочитати).
+func planImageTiles(raw []byte) []Region {
+	w, h, err := imageSize(raw)
+	if err != nil {
+		return nil
+	}
+	long, short := float64(h), float64(w)
+	vertical := true
+	if w > h {
+		long, short = float64(w), float64(h)
+		vertical = false
+	}
+	if long/short < tileAspectThreshold {
+		return nil
+	}
+
+	tileLen := short * tileAspect
+	n := int(math.Ceil((long - tileLen*tileOverlap) / (tileLen * (1 - tileOverlap))))
+	if n > maxTiles {
+		n = maxTiles
+		tileLen = long / (float64(n)*(1-tileOverlap) + tileOverlap)
+	}
+	if n < 2 {
+		return nil
+	}
+	step := (long - tileLen) / float64(n-1)
+
+	tiles := make([]Region, 0, n)
+	for i := 0; i < n; i++ {
+		start := float64(i) * step / long
+		size := tileLen / long
+		if vertical {
+			tiles = append(tiles, Region{X: 0, Y: start, Width: 1, Height: size})
+		} else {
+			tiles = append(tiles, Region{X: start, Y: 0, Width: size, Height: 1})
+		}
+	}
+	return tiles
+}
+
+// toParent переводить регіон з координат частини tile у координати всього зображення.
+func (r *Region) toParent(tile Region) *Region {
+	if r == nil {
+		return nil
+	}
+	return &Region{
+		X:      tile.X + r.X*tile.Width,
+		Y:      tile.Y + r.Y*tile.Height,
+		Width:  r.Width * tile.Width,
+		Height: r.Height * tile.Height,
+	}
+}
+
+// boxesInRegion повертає OCR-рядки, що лежать у tile, з координатами відносно tile.
+func boxesInRegion(boxes []TextBox, tile Region) []TextBox {
+	var out []TextBox
+	for _, b := range boxes {
+		cx, cy := b.Region.X+b.Region.Width/2, b.Region.Y+b.Region.Height/2
+		if cx < tile.X || cx > tile.X+tile.Width || cy < tile.Y || cy > tile.Y+tile.Height {
+			continue
+		}
+		out = append(out, TextBox{
+			Text: b.Text,
+			Region: Region{
+				X:      (b.Region.X - tile.X) / tile.Width,
+				Y:      (b.Region.Y - tile.Y) / tile.Height,
+				Width:  b.Region.Width / tile.Width,
+				Height: b.Region.Height / tile.Height,
+			},
+		})
+	}
+	return out
+}
+
+// CropImage вирізає частину скріншота (r у відносних координатах) і повертає її як PNG без втрати якості.
+func CropImage(raw []byte, r Region) ([]byte, error) {
+	nr := r.normalize()
+	if nr == nil {
+		return nil, fmt.Errorf("crop region is empty")
+	}
+	img, err := decodeImage(raw)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := png.Encode(&out, subImage(img, *nr)); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
 	}
 	return out.Bytes(), nil
 }
@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlanImageTiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		w, h     int
+		want     int // кількість частин; 0 — різати не треба
+		vertical bool
+	}{
+		{name: "regular screenshot", w: 1080, h: 1920},
+		{name: "just below threshold", w: 100, h: 219},
+		{name: "at threshold", w: 100, h: 220, want: 2, vertical: true},
+		{name: "long page", w: 100, h: 300, want: 3, vertical: true},
+		{name: "wide dashboard", w: 300, h: 100, want: 3},
+		{name: "very long page is capped", w: 100, h: 2000, want: maxTiles, vertical: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := planImageTiles(testPNG(t, tt.w, tt.h))
+			if len(tiles) != tt.want {
+				t.Fatalf("got %d tiles, want %d: %+v", len(tiles), tt.want, tiles)
+			}
+			for i, tile := range tiles {
+				// Уздовж короткої сторони частина займає все зображення.
+				start, size, across := tile.X, tile.Width, tile.Height
+				if tt.vertical {
+					start, size, across = tile.Y, tile.Height, tile.Width
+				}
+				if across != 1 {
+					t.Errorf("tile %d: %+v does not span the short side", i, tile)
+				}
+				if i == 0 && start != 0 {
+					t.Errorf("first tile starts at %v, want 0", start)
+				}
+				if i == len(tiles)-1 && math.Abs(start+size-1) > 1e-9 {
+					t.Errorf("last tile ends at %v, want 1", start+size)
+				}
+				if i > 0 {
+					prev := tiles[i-1]
+					prevEnd := prev.X + prev.Width
+					if tt.vertical {
+						prevEnd = prev.Y + prev.Height
+					}
+					if overlap := prevEnd - start; overlap < size*tileOverlap-1e-9 {
+						t.Errorf("tiles %d and %d overlap by %v, want at least %v", i-1, i, overlap, size*tileOverlap)
+					}
+				}
+			}
+		})
+	}
+
+	if tiles := planImageTiles([]byte("not an image")); tiles != nil {
+		t.Errorf("undecodable image: got %+v, want nil", tiles)
+	}
+}
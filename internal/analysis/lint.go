@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintCode — тип проблеми, яку лінтер знаходить у тест-кейсі.
+type LintCode string
+
+const (
+	LintVagueStep       LintCode = "vague-step"
+	LintNoPreconditions LintCode = "no-preconditions"
+	LintSameResult      LintCode = "expected-equals-actual"
+	LintEmptyResult     LintCode = "empty-result"
+	LintMissingVerb     LintCode = "missing-verb"
+	LintNonEnglish      LintCode = "non-english"
+)
+
+// lintPenalty — скільки кожна проблема знімає з оцінки 1.0.
+var lintPenalty = map[LintCode]float64{
+	LintVagueStep:       0.3,
+	LintNoPreconditions: 0.1,
+	LintSameResult:      0.4,
+	LintEmptyResult:     0.4,
+	LintMissingVerb:     0.15,
+	LintNonEnglish:      0.3,
+}
+
+// LintIssue — одна знайдена проблема з поясненням, яке можна показати і користувачу, і моделі.
+type LintIssue struct {
+	Code    LintCode
+	Message string
+}
+
+// LintResult — оцінка тест-кейсу: 1.0 — без зауважень, 0 — непридатний.
+type LintResult struct {
+	Score  float64
+	Issues []LintIssue
+}
+
+// Summary перелічує проблеми через "; ".
+func (r LintResult) Summary() string {
+	msgs := make([]string, len(r.Issues))
+	for i, is := range r.Issues {
+		msgs[i] = is.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// vagueStepPhrases — загальні кроки, з яких не зрозуміло, що саме робити (див. "BAD steps" у imagePrompt).
+var vagueStepPhrases = []string{
+	"open the affected screen", "perform the steps", "perform the action", "observe the result", "observe the behaviour", "observe the behavior",
+	"reproduce the bug", "reproduce the issue", "reproduce the steps", "follow the steps", "see actual result", "check the result",
+	"verify the issue", "trigger the bug", "as described", "do the action", "check the screen",
+}
+
+// stepVerbs — дієслова, з яких зазвичай починається конкретний крок.
+var stepVerbs = map[string]bool{
+	"open": true, "click": true, "tap": true, "double-click": true, "right-click": true, "press": true, "enter": true, "type": true,
+	"input": true, "fill": true, "paste": true, "clear": true, "select": true, "choose": true, "pick": true, "check": true, "uncheck": true,
+	"toggle": true, "enable": true, "disable": true, "navigate": true, "go": true, "visit": true, "return": true, "scroll": true,
+	"swipe": true, "drag": true, "hover": true, "zoom": true, "rotate": true, "resize": true, "submit": true, "save": true,
+	"upload": true, "download": true, "attach": true, "send": true, "add": true, "remove": true, "delete": true, "create": true,
+	"edit": true, "change": true, "set": true, "update": true, "switch": true, "expand": true, "collapse": true, "close": true,
+	"cancel": true, "confirm": true, "accept": true, "reject": true, "log": true, "sign": true, "login": true, "logout": true,
+	"register": true, "launch": true, "start": true, "restart": true, "reload": true, "refresh": true, "install": true,
+	"wait": true, "verify": true, "observe": true, "look": true, "note": true, "compare": true, "ensure": true, "search": true,
+	"find": true, "view": true, "read": true, "use": true, "try": true, "attempt": true, "repeat": true, "complete": true,
+	"continue": true, "proceed": true, "apply": true, "pay": true, "order": true, "book": true, "make": true, "hold": true,
+	"release": true, "focus": true, "leave": true, "move": true, "insert": true, "copy": true, "record": true, "turn": true,
+	"connect": true, "disconnect": true, "call": true, "run": true, "load": true, "access": true, "tab": true, "put": true,
+}
+
+// minStepsWithVerb — частка кроків, що мають починатися з дієслова.
+const minStepsWithVerb = 0.5
+
+// LintTestCase перевіряє тест-кейс на типові вади згенерованих кейсів і оцінює його.
+func LintTestCase(tc TestCase) LintResult {
+	var r LintResult
+	add := func(code LintCode, msg string) {
+		r.Issues = append(r.Issues, LintIssue{Code: code, Message: msg})
+	}
+
+	for _, step := range tc.Steps {
+		if isVagueStep(step) {
+			add(LintVagueStep, fmt.Sprintf("vague step %q", step))
+			break
+		}
+	}
+	if len(tc.Preconditions) == 0 {
+		add(LintNoPreconditions, "no preconditions")
+	}
+
+	exp, act := strings.TrimSpace(tc.Expected), strings.TrimSpace(tc.Actual)
+	switch {
+	case exp == "":
+		add(LintEmptyResult, "expected result is empty")
+	case act == "":
+		if !isAdditionalCase(tc) {
+			add(LintEmptyResult, "actual result is empty")
+		}
+	case strings.Join(Tokenize(exp), " ") == strings.Join(Tokenize(act), " "):
+		// Порівнюємо токени, а не схожість: "is visible" і "is not visible" дуже схожі, але це різні результати.
+		add(LintSameResult, "expected and actual results are the same")
+	}
+
+	if len(tc.Steps) > 0 {
+		withVerb := 0
+		for _, step := range tc.Steps {
+			if startsWithVerb(step) {
+				withVerb++
+			}
+		}
+		if float64(withVerb) < minStepsWithVerb*float64(len(tc.Steps)) {
+			add(LintMissingVerb, "steps do not start with an action verb (e.g. \"Click the 'Save' button\")")
+		}
+	}
+
+	text := tc.Title + "\n" + strings.Join(tc.Steps, "\n") + "\n" + exp + "\n" + act
+	if lang := DetectLanguage(text); lang != "" && lang != "en" {
+		add(LintNonEnglish, "text is not in English")
+	}
+
+	r.Score = 1
+	for _, is := range r.Issues {
+		r.Score -= lintPenalty[is.Code]
+	}
+	if r.Score < 0 {
+		r.Score = 0
+	}
+	return r
+}
+
+// isAdditionalCase — кейс режиму /mode: його ще не виконували, тож фактичного результату немає.
+func isAdditionalCase(tc TestCase) bool {
+	return tc.Category != "" && tc.Category != CategoryReproduction
+}
+
+func isVagueStep(step string) bool {
+	l := strings.ToLower(step)
+	for _, p := range vagueStepPhrases {
+		if strings.Contains(l, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsWithVerb перевіряє перше слово кроку; "User clicks ..." і "1. Click ..." теж рахуються.
+func startsWithVerb(step string) bool {
+	words := strings.Fields(strings.ToLower(step))
+	for len(words) > 0 && (strings.TrimRight(words[0], ".):") == "" || isNumbering(words[0]) || words[0] == "then" || words[0] == "and") {
+		words = words[1:]
+	}
+	if len(words) > 1 && (words[0] == "user" || (words[0] == "the" && words[1] == "user")) {
+		if words[0] == "the" {
+			words = words[1:]
+		}
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return false
+	}
+	w := strings.Trim(words[0], ".,:;!\"'")
+	if stepVerbs[w] {
+		return true
+	}
+	// "clicks", "opens", "presses" після "User".
+	for _, suffix := range []string{"es", "s"} {
+		if strings.HasSuffix(w, suffix) && stepVerbs[strings.TrimSuffix(w, suffix)] {
+			return true
+		}
+	}
+	return false
+}
+
+func isNumbering(w string) bool {
+	w = strings.TrimRight(w, ".):")
+	if w == "" {
+		return false
+	}
+	for _, r := range w {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
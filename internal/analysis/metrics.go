@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bugreportbot/internal/metrics"
+)
+
+var (
+	analysesTotal = metrics.NewCounter("bugreportbot_analyses_total",
+		"Analyses run, by backend (ANALYSIS_MODE), input kind, generation mode and outcome (ok, cached, error).",
+		"backend", "kind", "mode", "outcome")
+	analysisDuration = metrics.NewHistogram("bugreportbot_analysis_duration_seconds",
+		"Latency of analyses that were not served from the cache, by backend and input kind.",
+		nil, "backend", "kind")
+	fallbacksTotal = metrics.NewCounter("bugreportbot_fallbacks_total",
+		"Results built without a structured model answer: raw (fallbackFromRaw), template (FallbackTemplate), description (FallbackFromUserDescription).",
+		"kind")
+	ollamaRequestDuration = metrics.NewHistogram("bugreportbot_ollama_request_duration_seconds",
+		"Latency of Ollama HTTP calls, by endpoint and outcome.",
+		nil, "endpoint", "outcome")
+)
+
+// errReviewUnsupported — аналізатор не вміє перевіряти набори тест-кейсів.
+var errReviewUnsupported = errors.New("analyzer does not support test suite review")
+
+// instrumentedAnalyzer рахує аналізи й вимірює їх тривалість для /metrics.
+type instrumentedAnalyzer struct {
+	inner   Analyzer
+	backend string
+}
+
+// Instrument обгортає аналізатор метриками; backend — мітка бекенду (ollama, mock).
+// Обгортка реалізує Reviewer; якщо inner його не реалізує, Review повертає помилку.
+func Instrument(a Analyzer, backend string) Analyzer {
+	return &instrumentedAnalyzer{inner: a, backend: backend}
+}
+
+func (i *instrumentedAnalyzer) Analyze(ctx context.Context, image []byte) (*BugAnalysis, error) {
+	start := time.Now()
+	res, err := i.inner.Analyze(ctx, image)
+	i.observe(ctx, "image", start, res != nil && res.Cached, err)
+	return res, err
+}
+
+func (i *instrumentedAnalyzer) AnalyzeText(ctx context.Context, description string) (*BugAnalysis, error) {
+	start := time.Now()
+	res, err := i.inner.AnalyzeText(ctx, description)
+	i.observe(ctx, "text", start, res != nil && res.Cached, err)
+	return res, err
+}
+
+func (i *instrumentedAnalyzer) Review(ctx context.Context, existing []TestCase, report string, image []byte) (*CoverageReview, error) {
+	r, ok := i.inner.(Reviewer)
+	if !ok {
+		return nil, errReviewUnsupported
+	}
+	start := time.Now()
+	review, err := r.Review(ctx, existing, report, image)
+	i.observe(ctx, "review", start, false, err)
+	return review, err
+}
+
+func (i *instrumentedAnalyzer) observe(ctx context.Context, kind string, start time.Time, cached bool, err error) {
+	if errors.Is(err, ErrQuotaExceeded) {
+		// Аналізу не було: ліміти відмовили ще до виклику моделі.
+		return
+	}
+	outcome := "ok"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case cached:
+		outcome = "cached"
+	}
+	analysesTotal.Inc(i.backend, kind, string(GenerationModeFromContext(ctx)), outcome)
+	if !cached {
+		analysisDuration.Observe(time.Since(start).Seconds(), i.backend, kind)
+	}
+}
+
+// observeOllama записує тривалість HTTP-виклику Ollama.
+func observeOllama(endpoint string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	ollamaRequestDuration.Observe(time.Since(start).Seconds(), endpoint, outcome)
+}
@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"context"
+	"strings"
+)
+
+// Category — для чого написано тест-кейс.
+type Category string
+
+const (
+	// CategoryReproduction — кейс відтворює сам баг (єдина категорія до появи режимів).
+	CategoryReproduction Category = "reproduction"
+	// CategoryRegression — суміжна функціональність, яку може зламати виправлення.
+	CategoryRegression Category = "regression"
+	// CategoryNegative — некоректні дані, відсутні права, помилки мережі.
+	CategoryNegative Category = "negative"
+	// CategoryBoundary — граничні значення: порожні поля, мінімальна/максимальна довжина, ліміти.
+	CategoryBoundary Category = "boundary"
+)
+
+// ParseCategory нормалізує категорію з відповіді моделі; невідома або порожня — reproduction.
+func ParseCategory(v string) Category {
+	l := strings.ToLower(strings.TrimSpace(v))
+	switch {
+	case strings.HasPrefix(l, "regress"):
+		return CategoryRegression
+	case strings.HasPrefix(l, "negativ"), l == "error", l == "invalid":
+		return CategoryNegative
+	case strings.HasPrefix(l, "bound"), strings.HasPrefix(l, "edge"), l == "limit":
+		return CategoryBoundary
+	default:
+		return CategoryReproduction
+	}
+}
+
+// GenerationMode — які категорії тест-кейсів генерувати окрім відтворення бага.
+type GenerationMode string
+
+const (
+	ModeBug        GenerationMode = "bug"
+	ModeRegression GenerationMode = "regression"
+	ModeNegative   GenerationMode = "negative"
+	ModeBoundary   GenerationMode = "boundary"
+	ModeFull       GenerationMode = "full"
+)
+
+// GenerationModes — усі режими в порядку, у якому їх показує /mode.
+var GenerationModes = []GenerationMode{ModeBug, ModeRegression, ModeNegative, ModeBoundary, ModeFull}
+
+// ParseGenerationMode розпізнає назву режиму (без урахування регістру).
+func ParseGenerationMode(v string) (GenerationMode, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" || v == "off" || v == "default" {
+		return ModeBug, true
+	}
+	for _, m := range GenerationModes {
+		if string(m) == v {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// ExtraCategories повертає категорії, які режим додає до кейсів відтворення бага.
+func (m GenerationMode) ExtraCategories() []Category {
+	switch m {
+	case ModeRegression:
+		return []Category{CategoryRegression}
+	case ModeNegative:
+		return []Category{CategoryNegative}
+	case ModeBoundary:
+		return []Category{CategoryBoundary}
+	case ModeFull:
+		return []Category{CategoryRegression, CategoryNegative, CategoryBoundary}
+	default:
+		return nil
+	}
+}
+
+// categoryInstructions — що саме просити у моделі для кожної додаткової категорії.
+var categoryInstructions = map[Category]string{
+	CategoryRegression: `2-3 REGRESSION cases (category "regression"): neighbouring functionality of the same feature that a fix for this bug could break.`,
+	CategoryNegative:   `2-3 NEGATIVE cases (category "negative"): invalid or missing input, wrong permissions, network or server errors around the same feature.`,
+	CategoryBoundary:   `2-3 BOUNDARY cases (category "boundary"): empty values, minimum/maximum lengths and amounts, limits and off-by-one values of the same feature.`,
+}
+
+// modePromptSection — додаток до промпта, що просить додаткові категорії кейсів ("" для ModeBug).
+func modePromptSection(m GenerationMode) string {
+	extra := m.ExtraCategories()
+	if len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nADDITIONAL TEST CASES: besides the cases that reproduce the bug (category \"reproduction\"), also write around the same feature:\n")
+	for _, c := range extra {
+		b.WriteString("- " + categoryInstructions[c] + "\n")
+	}
+	b.WriteString("Additional cases have not been run yet: leave their \"actualResult\" empty. The 2–6 limit applies to reproduction cases only.\n")
+	return b.String()
+}
+
+type generationModeKey struct{}
+
+// WithGenerationMode передає режим генерації в аналізатор.
+func WithGenerationMode(ctx context.Context, m GenerationMode) context.Context {
+	return context.WithValue(ctx, generationModeKey{}, m)
+}
+
+// GenerationModeFromContext повертає режим, переданий через WithGenerationMode, або ModeBug.
+func GenerationModeFromContext(ctx context.Context) GenerationMode {
+	if m, ok := ctx.Value(generationModeKey{}).(GenerationMode); ok && m != "" {
+		return m
+	}
+	return ModeBug
+}
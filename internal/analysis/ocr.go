@@ -0,0 +1,358 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TextBox — рядок тексту, розпізнаний на скріншоті; координати відносні (0..1), як у Region.
+type TextBox struct {
+	Text   string
+	Region Region
+}
+
+// OCR розпізнає видимий текст на зображенні.
+type OCR interface {
+	Recognize(ctx context.Context, image []byte) ([]TextBox, error)
+}
+
+// TesseractOCR викликає локальний tesseract CLI (https://github.com/tesseract-ocr/tesseract) у режимі TSV.
+type TesseractOCR struct {
+	path string
+	lang string
+}
+
+func NewTesseractOCR(path, lang string) *TesseractOCR {
+	if path == "" {
+		path = "tesseract"
+	}
+	if lang == "" {
+		lang = "eng"
+	}
+	return &TesseractOCR{path: path, lang: lang}
+}
+
+func (t *TesseractOCR) Recognize(ctx context.Context, img []byte) ([]TextBox, error) {
+	w, h, err := imageSize(img)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.lang, "tsv")
+	cmd.Stdin = bytes.NewReader(img)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("run tesseract: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(out, w, h), nil
+}
+
+// parseTesseractTSV збирає слова з TSV-виводу tesseract у рядки (block/par/line) з об'єднаними рамками.
+func parseTesseractTSV(tsv []byte, w, h int) []TextBox {
+	type line struct {
+		words                  []string
+		left, top, right, bott int
+	}
+	var order []string
+	lines := make(map[string]*line)
+
+	sc := bufio.NewScanner(bytes.NewReader(tsv))
+	header := true
+	for sc.Scan() {
+		if header {
+			header = false
+			continue
+		}
+		// level page block par line word left top width height conf text
+		cols := strings.Split(sc.Text(), "\t")
+		if len(cols) < 12 || cols[0] != "5" {
+			continue
+		}
+		text := strings.TrimSpace(cols[11])
+		if text == "" {
+			continue
+		}
+		if conf, err := strconv.ParseFloat(cols[10], 64); err == nil && conf >= 0 && conf < 30 {
+			continue
+		}
+		left, _ := strconv.Atoi(cols[6])
+		top, _ := strconv.Atoi(cols[7])
+		width, _ := strconv.Atoi(cols[8])
+		height, _ := strconv.Atoi(cols[9])
+
+		key := cols[2] + "/" + cols[3] + "/" + cols[4]
+		l := lines[key]
+		if l == nil {
+			l = &line{left: left, top: top, right: left + width, bott: top + height}
+			lines[key] = l
+			order = append(order, key)
+		}
+		l.words = append(l.words, text)
+		l.left, l.top = minInt(l.left, left), minInt(l.top, top)
+		l.right, l.bott = maxInt(l.right, left+width), maxInt(l.bott, top+height)
+	}
+
+	out := make([]TextBox, 0, len(order))
+	for _, key := range order {
+		l := lines[key]
+		out = append(out, TextBox{
+			Text:   strings.Join(l.words, " "),
+			Region: pixelRegion(l.left, l.top, l.right-l.left, l.bott-l.top, w, h),
+		})
+	}
+	return out
+}
+
+// HTTPOCR надсилає зображення на зовнішній OCR-сервіс: POST з байтами зображення,
+// у відповідь JSON {"lines":[{"text":"Sign in","left":10,"top":20,"width":100,"height":30}]} (пікселі).
+type HTTPOCR struct {
+	url    string
+	client *http.Client
+}
+
+// maxOCRResponseBytes обмежує відповідь OCR-сервісу: навіть дуже текстовий скріншот — це десятки КБ JSON.
+const maxOCRResponseBytes = 4 << 20
+
+func NewHTTPOCR(url string) *HTTPOCR {
+	return &HTTPOCR{
+		url: url,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+func (o *HTTPOCR) Recognize(ctx context.Context, img []byte) ([]TextBox, error) {
+	w, h, err := imageSize(img)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(img))
+	if err != nil {
+		return nil, fmt.Errorf("create ocr request: %w", err)
+	}
+	req.Header.Set("Content-Type", http.DetectContentType(img))
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("call ocr service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOCRResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read ocr response: %w", err)
+	}
+	if len(body) > maxOCRResponseBytes {
+		return nil, fmt.Errorf("ocr response is larger than %d bytes", maxOCRResponseBytes)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ocr http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var dto struct {
+		Lines []struct {
+			Text   string `json:"text"`
+			Left   int    `json:"left"`
+			Top    int    `json:"top"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(body, &dto); err != nil {
+		return nil, fmt.Errorf("decode ocr response: %w", err)
+	}
+	out := make([]TextBox, 0, len(dto.Lines))
+	for _, l := range dto.Lines {
+		if strings.TrimSpace(l.Text) == "" {
+			continue
+		}
+		out = append(out, TextBox{Text: strings.TrimSpace(l.Text), Region: pixelRegion(l.Left, l.Top, l.Width, l.Height, w, h)})
+	}
+	return out, nil
+}
+
+// CachingOCR запам'ятовує результати OCR за SHA-256 зображення: редактор і аналізатор отримують той самий
+// скріншот один за одним, і без кешу OCR запускався б двічі.
+type CachingOCR struct {
+	ocr        OCR
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List // найсвіжіші спереду
+	entries    map[[sha256.Size]byte]*list.Element
+}
+
+type ocrCacheEntry struct {
+	key   [sha256.Size]byte
+	boxes []TextBox
+}
+
+// NewCachingOCR обгортає ocr кешем на maxEntries зображень.
+func NewCachingOCR(ocr OCR, maxEntries int) *CachingOCR {
+	return &CachingOCR{
+		ocr:        ocr,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+func (c *CachingOCR) Recognize(ctx context.Context, img []byte) ([]TextBox, error) {
+	key := sha256.Sum256(img)
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		boxes := el.Value.(*ocrCacheEntry).boxes
+		c.mu.Unlock()
+		return append([]TextBox(nil), boxes...), nil
+	}
+	c.mu.Unlock()
+
+	boxes, err := c.ocr.Recognize(ctx, img)
+	if err != nil {
+		return nil, err
+	}
+	c.Remember(img, boxes)
+	return boxes, nil
+}
+
+// Remember зберігає текст зображення, розпізнаний деінде, наприклад текст скріншота після редагування.
+func (c *CachingOCR) Remember(img []byte, boxes []TextBox) {
+	if c.maxEntries <= 0 {
+		return
+	}
+	key := sha256.Sum256(img)
+	entry := &ocrCacheEntry{key: key, boxes: append([]TextBox(nil), boxes...)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.maxEntries {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*ocrCacheEntry).key)
+	}
+}
+
+func imageSize(img []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return 0, 0, fmt.Errorf("decode image config: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return 0, 0, fmt.Errorf("invalid image size")
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func pixelRegion(left, top, width, height, w, h int) Region {
+	return Region{
+		X:      float64(left) / float64(w),
+		Y:      float64(top) / float64(h),
+		Width:  float64(width) / float64(w),
+		Height: float64(height) / float64(h),
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// maxOCRLinesInPrompt обмежує, скільки рядків OCR потрапляє в промпт (щоб не роздувати контекст моделі).
+const maxOCRLinesInPrompt = 60
+
+// ocrPromptSection формує блок промпту з розпізнаним текстом; порожній рядок, якщо тексту немає.
+func ocrPromptSection(boxes []TextBox) string {
+	if len(boxes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nTEXT VISIBLE ON THE SCREENSHOT (from OCR; x,y = position as fraction of width/height). Use these EXACT labels when you quote buttons, fields and messages:\n")
+	for i, tb := range boxes {
+		if i == maxOCRLinesInPrompt {
+			b.WriteString("- ...\n")
+			break
+		}
+		b.WriteString(fmt.Sprintf("- %q at (%.2f, %.2f)\n", tb.Text, tb.Region.X, tb.Region.Y))
+	}
+	return b.String()
+}
+
+var quotedLabelRe = regexp.MustCompile(`'([^']{2,60})'|"([^"]{2,60})"|“([^”]{2,60})”`)
+
+// quotedLabels повертає підписи в лапках з кроку ("Click the 'Save' button" → Save).
+func quotedLabels(step string) []string {
+	var out []string
+	for _, m := range quotedLabelRe.FindAllStringSubmatch(step, -1) {
+		for _, g := range m[1:] {
+			if g != "" {
+				out = append(out, g)
+			}
+		}
+	}
+	return out
+}
+
+// CheckLabelsAgainstOCR перевіряє, що підписи в лапках у Steps справді є на скріншоті,
+// і повертає примітки для кожного підпису, якого OCR не знайшов.
+func CheckLabelsAgainstOCR(cases []TestCase, boxes []TextBox) []string {
+	if len(boxes) == 0 {
+		return nil
+	}
+	var screen strings.Builder
+	for _, tb := range boxes {
+		screen.WriteString(normalizeLabel(tb.Text))
+		screen.WriteString(" ")
+	}
+	screenText := screen.String()
+
+	var notes []string
+	seen := make(map[string]bool)
+	for _, tc := range cases {
+		for _, step := range tc.Steps {
+			for _, label := range quotedLabels(step) {
+				norm := normalizeLabel(label)
+				if norm == "" || seen[norm] || strings.Contains(screenText, norm) {
+					continue
+				}
+				seen[norm] = true
+				notes = append(notes, fmt.Sprintf("Test case %s: label %q was not found on the screenshot — verify the step.", caseRef(tc), label))
+			}
+		}
+	}
+	return notes
+}
+
+// normalizeLabel зводить текст до нижнього регістру і одиночних пробілів без розділових знаків.
+func normalizeLabel(s string) string {
+	return strings.Join(Tokenize(s), " ")
+}
@@ -7,10 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
+
+	"bugreportbot/internal/logging"
+	"bugreportbot/internal/tracing"
 )
 
 // OllamaAnalyzer викликає локальний Ollama (vision модель) для аналізу зображень.
@@ -19,6 +22,11 @@ type OllamaAnalyzer struct {
 	baseURL string
 	model   string
 	client  *http.Client
+	cache   *AnalysisCache
+	ocr     OCR
+	scales  *ScaleConfig
+	// repairBelow — тест-кейси з оцінкою лінтера нижче цього значення переписуються повторним запитом (0 — вимкнено).
+	repairBelow float64
 }
 
 func NewOllamaAnalyzer(baseURL, model string) *OllamaAnalyzer {
@@ -32,6 +40,47 @@ func NewOllamaAnalyzer(baseURL, model string) *OllamaAnalyzer {
 	}
 }
 
+// imagePromptVersion змінюється разом із промптом для скріншотів, щоб старі результати в кеші не використовувались.
+const imagePromptVersion = "image-v4"
+
+// SetCache вмикає кешування результатів аналізу скріншотів (nil — вимкнути).
+func (a *OllamaAnalyzer) SetCache(c *AnalysisCache) {
+	a.cache = c
+}
+
+// SetOCR вмикає розпізнавання тексту на скріншоті перед викликом моделі (nil — вимкнути).
+func (a *OllamaAnalyzer) SetOCR(o OCR) {
+	a.ocr = o
+}
+
+// SetScaleConfig задає правила нормалізації Priority/Severity (nil — лише вбудовані синоніми).
+func (a *OllamaAnalyzer) SetScaleConfig(c *ScaleConfig) {
+	a.scales = c
+}
+
+// normalizeScales застосовує правила пріоритетів проєкту з контексту (див. WithProject).
+func (a *OllamaAnalyzer) normalizeScales(ctx context.Context, res *BugAnalysis) {
+	NormalizeScales(res, a.scales, ProjectFromContext(ctx))
+}
+
+// SetRepairThreshold вмикає перевірку тест-кейсів лінтером: кейси з оцінкою нижче min
+// модель переписує повторним запитом перед відправкою (0 — вимкнути).
+func (a *OllamaAnalyzer) SetRepairThreshold(min float64) {
+	a.repairBelow = min
+}
+
+// imagePromptVersionFor враховує, чи додається OCR-текст і режим генерації у промпт: з ними результати різні.
+func (a *OllamaAnalyzer) imagePromptVersionFor(mode GenerationMode) string {
+	v := imagePromptVersion
+	if a.ocr != nil {
+		v += "+ocr"
+	}
+	if mode != ModeBug {
+		v += "+" + string(mode)
+	}
+	return v
+}
+
 // CheckOllamaReachable перевіряє, чи доступний Ollama за вказаною URL (при старті бота).
 func CheckOllamaReachable(baseURL string) error {
 	url := strings.TrimRight(baseURL, "/") + "/api/tags"
@@ -65,21 +114,8 @@ type ollamaGenerateResponse struct {
 	Error    string `json:"error,omitempty"`
 }
 
-func (a *OllamaAnalyzer) Analyze(ctx context.Context, image []byte) (*BugAnalysis, error) {
-	if len(image) == 0 {
-		return nil, fmt.Errorf("empty image")
-	}
-
-	// Зменшити та стиснути зображення, щоб Ollama не таймаутила на великих фото з Telegram.
-	prepared, err := prepareImageForOllama(image)
-	if err != nil {
-		log.Printf("ollama: prepare image failed, using original: %v", err)
-		prepared = image
-	}
-	log.Printf("[ollama] analyzing image: original=%d bytes, prepared=%d bytes", len(image), len(prepared))
-	imgB64 := base64.StdEncoding.EncodeToString(prepared)
-
-	prompt := `You are a senior QA engineer. Analyze this UI screenshot and write CONCRETE, SPECIFIC test cases.
+// imagePrompt — інструкція для vision-моделі; при зміні оновіть imagePromptVersion.
+const imagePrompt = `You are a senior QA engineer. Analyze this UI screenshot and write CONCRETE, SPECIFIC test cases.
 
 WHAT TO DO:
 1) Look at the screenshot and name what you see: app/screen name, buttons, labels, fields, messages, layout.
@@ -96,6 +132,12 @@ BE SPECIFIC — bad vs good:
 Return STRICT JSON ONLY in ENGLISH (no markdown, no other text):
 {
   "bugTitle": "string (short, specific: e.g. 'Save button truncated on Settings screen')",
+  "summary": "string (1-2 sentences: what is broken and how it affects the user)",
+  "component": "string (feature or area of the app, e.g. 'Settings', 'Checkout')",
+  "environment": {"os": "string", "device": "string (browser or device)", "appVersion": "string"},
+  "reproducibility": "Always | Sometimes | Once | Unknown",
+  "tags": ["string (short lowercase labels, e.g. 'ui', 'layout')"],
+  "rootCauseHypothesis": "string (likely cause, e.g. 'fixed-width container does not fit the translated label')",
   "testCases": [
     {
       "id": "TC-001",
@@ -105,129 +147,250 @@ Return STRICT JSON ONLY in ENGLISH (no markdown, no other text):
       "expectedResult": "string (what should happen, specific)",
       "actualResult": "string (what is wrong on the screenshot, specific)",
       "priority": "High | Medium | Low",
-      "severity": "Critical | Major | Minor | Trivial"
+      "severity": "Critical | Major | Minor | Trivial",
+      "category": "reproduction | regression | negative | boundary",
+      "region": {"x": 0.0, "y": 0.0, "width": 0.0, "height": 0.0}
     }
   ]
 }
 Rules:
 - 2–6 test cases. Each step and expected/actual must describe what is VISIBLE on the screenshot (names of buttons, labels, error text).
+- Fill "environment" only with what is visible on the screenshot (status bar, browser chrome, version label); leave unknown fields as "". Use "Unknown" reproducibility unless the screenshot shows otherwise.
+- "region" is the bounding box of the defective UI element as fractions of the image size (0..1; x,y = top-left corner). Omit it if the problem has no single location.
 - All text in English only. priority/severity: High=must fix, Medium=important, Low=minor; Critical/Major/Minor/Trivial for impact.
 - Ignore pure accessibility (contrast, ARIA) unless it breaks normal use.
 `
 
-	reqBody := ollamaGenerateRequest{
-		Model:  a.model,
-		Prompt: prompt,
-		Images: []string{imgB64},
-		Stream: false,
+func (a *OllamaAnalyzer) Analyze(ctx context.Context, image []byte) (*BugAnalysis, error) {
+	if len(image) == 0 {
+		return nil, fmt.Errorf("empty image")
+	}
+
+	// Зменшити та стиснути зображення, щоб Ollama не таймаутила на великих фото з Telegram.
+	prepared, hash, err := prepareImageTraced(ctx, image)
+	cacheable := err == nil && a.cache != nil
+	if err != nil {
+		slog.WarnContext(ctx, "ollama: prepare image failed, using original", "err", err)
+		prepared = image
+	}
+	mode := GenerationModeFromContext(ctx)
+	cacheKey := CacheKey{ImageHash: hash, Model: a.model, PromptVersion: a.imagePromptVersionFor(mode)}
+	if cacheable && !ForceRefresh(ctx) {
+		if cached := a.cache.Get(cacheKey); cached != nil {
+			slog.InfoContext(ctx, "ollama: cache hit for image", "phash", fmt.Sprintf("%016x", hash))
+			a.normalizeScales(ctx, cached)
+			return cached, nil
+		}
+	}
+	if err := allowModelCall(ctx); err != nil {
+		return nil, err
+	}
+
+	// OCR по оригіналу (краща роздільність): llava часто помиляється в підписах кнопок і текстах помилок.
+	var ocrBoxes []TextBox
+	if a.ocr != nil {
+		ocrBoxes, err = a.ocr.Recognize(ctx, image)
+		if err != nil {
+			slog.WarnContext(ctx, "ollama: OCR failed, analyzing without it", "err", err)
+		} else {
+			slog.DebugContext(ctx, "ollama: OCR found text lines", "lines", len(ocrBoxes))
+		}
 	}
 
+	var out *BugAnalysis
+	structured := false
+	if tiles := planImageTiles(image); len(tiles) > 1 {
+		// Довгі скріншоти (скролшоти) аналізуються частинами, інакше після зменшення до 1024px текст нечитабельний.
+		out, structured, err = a.analyzeTiles(ctx, image, tiles, ocrBoxes, mode)
+	} else {
+		slog.InfoContext(ctx, "ollama: analyzing image", "original_bytes", len(image), "prepared_bytes", len(prepared))
+		out, structured, err = a.analyzeImageOnce(ctx, prepared, imagePrompt+ocrPromptSection(ocrBoxes)+modePromptSection(mode))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !structured {
+		// Модель не дотрималась JSON-контракту — повертаємо raw fallback, не кешуючи його.
+		a.normalizeScales(ctx, out)
+		return out, nil
+	}
+
+	if out.BugTitle == "" {
+		out.BugTitle = "Bug found based on screenshot analysis"
+	}
+	if len(out.TestCases) == 0 {
+		// Фолбек, щоб бот завжди повертав щось корисне.
+		out.TestCases = []TestCase{
+			{
+				ID:       "TC-001",
+				Title:    "Verify visual appearance of the UI element on the screenshot",
+				Steps:    []string{"Open the screen shown on the screenshot", "Check that key UI elements are fully visible and readable"},
+				Expected: "UI elements are fully visible, readable and not overlapping or truncated",
+				Actual:   "There is a visual problem on the screen according to the screenshot",
+				Priority: "Medium",
+				Severity: "Major",
+			},
+		}
+	}
+
+	a.repairTestCases(ctx, out)
+	out.Notes = append(out.Notes, CheckLabelsAgainstOCR(out.TestCases, ocrBoxes)...)
+
+	// У кеші — значення моделі як є: правила пріоритетів залежать від проєкту, а ключ кешу — ні.
+	if cacheable {
+		a.cache.Put(cacheKey, out)
+	}
+	a.normalizeScales(ctx, out)
+	return out, nil
+}
+
+// generate викликає /api/generate без стрімінгу і повертає текст відповіді моделі.
+func (a *OllamaAnalyzer) generate(ctx context.Context, reqBody ollamaGenerateRequest) (_ string, err error) {
+	start := time.Now()
+	var span *tracing.Span
+	defer func() {
+		observeOllama("generate", start, err)
+		span.RecordError(err)
+		span.End()
+	}()
+
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(&reqBody); err != nil {
-		return nil, fmt.Errorf("encode ollama request: %w", err)
+		return "", fmt.Errorf("encode ollama request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/api/generate", &buf)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		return "", fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if id := logging.CorrelationID(ctx); id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	span = startOllamaSpan(ctx, req, "generate", reqBody.Model)
+	span.SetAttributes(tracing.Int("prompt_len", len(reqBody.Prompt)), tracing.Int("images", len(reqBody.Images)))
 
 	resp, err := a.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("call ollama: %w", err)
+		return "", fmt.Errorf("call ollama: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("ollama http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return "", fmt.Errorf("ollama http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	var genResp ollamaGenerateResponse
 	if err := json.Unmarshal(body, &genResp); err != nil {
-		return nil, fmt.Errorf("decode ollama response: %w (raw=%s)", err, strings.TrimSpace(string(body)))
+		return "", fmt.Errorf("decode ollama response: %w (raw=%s)", err, strings.TrimSpace(string(body)))
 	}
 	if genResp.Error != "" {
-		return nil, fmt.Errorf("ollama error: %s", genResp.Error)
+		return "", fmt.Errorf("ollama error: %s", genResp.Error)
 	}
 
 	respPreview := genResp.Response
 	if len(respPreview) > 500 {
 		respPreview = respPreview[:500] + "..."
 	}
-	log.Printf("ollama: response len=%d, preview=%q", len(genResp.Response), strings.TrimSpace(respPreview))
-
-	// Модель може повертати JSON у блоці ```json ... ``` — спочатку прибираємо обгортку.
-	responseBody := stripMarkdownCodeBlock(genResp.Response)
-	jsonText := extractFirstJSONObject(responseBody)
+	slog.DebugContext(ctx, "ollama: response", "len", len(genResp.Response), "preview", strings.TrimSpace(respPreview))
+	span.SetAttributes(tracing.Int("response_len", len(genResp.Response)))
+	return genResp.Response, nil
+}
 
-	// Внутрішній DTO; steps/preconditions приймає і рядок, і масив (модель іноді ламає схему).
-	var dto struct {
-		BugTitle  string `json:"bugTitle"`
-		TestCases []struct {
-			ID            string          `json:"id"`
-			Title         string          `json:"title"`
-			Preconditions flexStringSlice `json:"preconditions"`
-			Steps         flexStringSlice `json:"steps"`
-			Expected      string          `json:"expectedResult"`
-			Actual        string          `json:"actualResult"`
-			Priority      string          `json:"priority"`
-			Severity      string          `json:"severity"`
-		} `json:"testCases"`
+// analyzeImageOnce надсилає одне підготовлене зображення з промптом і розбирає відповідь.
+// structured=false означає, що модель не повернула JSON і результат — fallbackFromRaw.
+func (a *OllamaAnalyzer) analyzeImageOnce(ctx context.Context, prepared []byte, prompt string) (*BugAnalysis, bool, error) {
+	response, err := a.generate(ctx, ollamaGenerateRequest{
+		Model:  a.model,
+		Prompt: prompt,
+		Images: []string{base64.StdEncoding.EncodeToString(prepared)},
+	})
+	if err != nil {
+		return nil, false, err
 	}
 
+	// Модель може повертати JSON у блоці ```json ... ``` — extractJSON спочатку прибирає обгортку.
+	jsonText := extractJSON(ctx, response)
+
+	var dto ollamaAnalysisDTO
+
 	// Якщо модель не повернула JSON, використовуємо raw-текст як fallback.
 	if jsonText == "" {
-		log.Printf("ollama: no JSON object detected in response, using raw fallback")
-		return fallbackFromRaw(genResp.Response), nil
+		slog.WarnContext(ctx, "ollama: no JSON object detected in response, using raw fallback")
+		return fallbackFromRaw(response), false, nil
 	}
 
 	if err := json.Unmarshal([]byte(jsonText), &dto); err != nil {
-		log.Printf("ollama: JSON parse error: %v, snippet=%q", err, truncate(jsonText, 300))
-		return fallbackFromRaw(genResp.Response), nil
+		slog.WarnContext(ctx, "ollama: JSON parse error, using raw fallback", "err", err, "snippet", truncate(jsonText, 300))
+		return fallbackFromRaw(response), false, nil
 	}
 
-	log.Printf("ollama: parsed bugTitle=%q, testCases=%d", dto.BugTitle, len(dto.TestCases))
+	slog.DebugContext(ctx, "ollama: parsed analysis", "bug_title", dto.BugTitle, "test_cases", len(dto.TestCases))
 
-	out := &BugAnalysis{
-		BugTitle: dto.BugTitle,
-	}
-	for _, tc := range dto.TestCases {
-		steps := []string(tc.Steps)
-		if len(steps) == 0 {
-			steps = []string{"See actual result"}
+	return dto.toBugAnalysis(), true, nil
+}
+
+// analyzeTiles аналізує кожну частину довгого скріншота окремо і зводить результати в один BugAnalysis:
+// регіони переводяться в координати всього зображення, дублікати з областей перекриття прибираються.
+// Додаткові категорії (mode) просимо лише в першій частині, щоб вони не повторювались для кожної.
+func (a *OllamaAnalyzer) analyzeTiles(ctx context.Context, image []byte, tiles []Region, ocrBoxes []TextBox, mode GenerationMode) (*BugAnalysis, bool, error) {
+	img, err := decodeImage(image)
+	if err != nil {
+		return nil, false, err
+	}
+	out := &BugAnalysis{}
+	structured := false
+	for i, tile := range tiles {
+		prepared, err := prepareImageTile(img, tile)
+		if err != nil {
+			return nil, false, fmt.Errorf("prepare tile %d: %w", i+1, err)
 		}
-		out.TestCases = append(out.TestCases, TestCase{
-			ID:            tc.ID,
-			Title:         tc.Title,
-			Preconditions: []string(tc.Preconditions),
-			Steps:         steps,
-			Expected:      tc.Expected,
-			Actual:        tc.Actual,
-			Priority:      tc.Priority,
-			Severity:      tc.Severity,
-		})
+		slog.InfoContext(ctx, "ollama: analyzing tile", "tile", i+1, "tiles", len(tiles), "prepared_bytes", len(prepared))
+
+		prompt := imagePrompt + fmt.Sprintf("\nThis image is part %d of %d of one long screenshot (ordered top-to-bottom / left-to-right); neighbouring parts overlap slightly. Report only problems visible in THIS part.\n", i+1, len(tiles)) +
+			ocrPromptSection(boxesInRegion(ocrBoxes, tile))
+		if i == 0 {
+			prompt += modePromptSection(mode)
+		}
+		part, ok, err := a.analyzeImageOnce(ctx, prepared, prompt)
+		if err != nil {
+			return nil, false, fmt.Errorf("tile %d/%d: %w", i+1, len(tiles), err)
+		}
+		structured = structured || ok
+		if out.BugTitle == "" && ok {
+			out.BugTitle = part.BugTitle
+		}
+		for j := range part.TestCases {
+			part.TestCases[j].Region = part.TestCases[j].Region.toParent(tile)
+		}
+		MergeAnalyses(out, part)
 	}
-	if out.BugTitle == "" {
-		out.BugTitle = "Bug found based on screenshot analysis"
+	if !structured {
+		return out, false, nil
 	}
-	if len(out.TestCases) == 0 {
-		// Фолбек, щоб бот завжди повертав щось корисне.
-		out.TestCases = []TestCase{
-			{
-				ID:       "TC-001",
-				Title:    "Verify visual appearance of the UI element on the screenshot",
-				Steps:    []string{"Open the screen shown on the screenshot", "Check that key UI elements are fully visible and readable"},
-				Expected: "UI elements are fully visible, readable and not overlapping or truncated",
-				Actual:   "There is a visual problem on the screen according to the screenshot",
-				Priority: "Medium",
-				Severity: "Major",
-			},
+	// Raw-fallback кейси окремих частин не мають сенсу поряд зі структурованими.
+	kept := out.TestCases[:0]
+	for _, tc := range out.TestCases {
+		if !isRawID(tc.ID) {
+			kept = append(kept, tc)
 		}
 	}
+	out.TestCases = kept
+	return out, true, nil
+}
 
-	return out, nil
+// tileDuplicateSimilarity — з якої подібності кейси сусідніх частин вважаються одним і тим самим багом.
+const tileDuplicateSimilarity = 0.85
+
+func containsSimilarTestCase(cases []TestCase, tc TestCase) bool {
+	text := TestCaseText(tc)
+	for _, c := range cases {
+		if TextSimilarity(text, TestCaseText(c)) >= tileDuplicateSimilarity {
+			return true
+		}
+	}
+	return false
 }
 
 // AnalyzeText аналізує текстовий опис бага (у будь-якій мові) та повертає тест-кейси.
@@ -245,6 +408,12 @@ Ignore accessibility-only concerns (contrast, focus order, screen reader labels,
 Return STRICT JSON ONLY in ENGLISH (no markdown, no explanations, no extra text) with this schema:
 {
   "bugTitle": "string",
+  "summary": "string (1-2 sentences: what is broken and how it affects the user)",
+  "component": "string (feature or area of the app, e.g. 'Login', 'Checkout')",
+  "environment": {"os": "string", "device": "string (browser or device)", "appVersion": "string"},
+  "reproducibility": "Always | Sometimes | Once | Unknown",
+  "tags": ["string (short lowercase labels)"],
+  "rootCauseHypothesis": "string (likely cause; keep it short)",
   "testCases": [
     {
       "id": "TC-001",
@@ -254,7 +423,8 @@ Return STRICT JSON ONLY in ENGLISH (no markdown, no explanations, no extra text)
       "expectedResult": "string",
       "actualResult": "string",
       "priority": "High | Medium | Low",
-      "severity": "Critical | Major | Minor | Trivial"
+      "severity": "Critical | Major | Minor | Trivial",
+      "category": "reproduction | regression | negative | boundary"
     }
   ]
 }
@@ -263,78 +433,312 @@ Rules:
 - All text MUST be in English only.
 - Choose priority based on business impact (High = must fix now, Medium = important but not blocking, Low = nice to have).
 - Choose severity based on impact on functionality and users (Critical, Major, Minor, Trivial).
+- Fill "environment" and "reproducibility" only from what the tester wrote; leave unknown fields as "" and use "Unknown" reproducibility if it is not stated.
 
+` + modePromptSection(GenerationModeFromContext(ctx)) + `
 Bug description from tester:
 ` + desc + `
 `
 
-	reqBody := ollamaGenerateRequest{
-		Model:  a.model,
-		Prompt: prompt,
-		Stream: false,
+	response, err := a.generate(ctx, ollamaGenerateRequest{Model: a.model, Prompt: prompt})
+	if err != nil {
+		return nil, fmt.Errorf("text analysis: %w", err)
 	}
 
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(&reqBody); err != nil {
-		return nil, fmt.Errorf("encode ollama text request: %w", err)
-	}
+	jsonText := extractJSON(ctx, response)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/api/generate", &buf)
-	if err != nil {
-		return nil, fmt.Errorf("create text request: %w", err)
+	var dto ollamaAnalysisDTO
+
+	// Якщо модель не повернула JSON, використовуємо raw-текст як fallback.
+	if jsonText == "" {
+		slog.WarnContext(ctx, "ollama text: no JSON object detected, using raw response fallback")
+		return fallbackFromRaw(response), nil
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := a.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("call ollama (text): %w", err)
+	if err := json.Unmarshal([]byte(jsonText), &dto); err != nil {
+		slog.WarnContext(ctx, "ollama text: failed to parse JSON, using raw response fallback", "err", err, "json", jsonText)
+		return fallbackFromRaw(response), nil
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("ollama text http %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	out := dto.toBugAnalysis()
+	for i := range out.TestCases {
+		// Регіони мають сенс лише для скріншотів.
+		out.TestCases[i].Region = nil
+	}
+	if out.BugTitle == "" {
+		out.BugTitle = "Bug found based on textual description"
+	}
+	if len(out.TestCases) == 0 {
+		out.TestCases = []TestCase{
+			{
+				ID:       "TC-001",
+				Title:    "Verify behaviour described in the bug report",
+				Steps:    []string{"Follow the steps from the tester description", "Observe the behaviour that should be fixed"},
+				Expected: "The application behaves according to the functional requirements",
+				Actual:   desc,
+				Priority: "Medium",
+				Severity: "Major",
+			},
+		}
 	}
+	a.repairTestCases(ctx, out)
+	a.normalizeScales(ctx, out)
 
-	var genResp ollamaGenerateResponse
-	if err := json.Unmarshal(body, &genResp); err != nil {
-		return nil, fmt.Errorf("decode ollama text response: %w (raw=%s)", err, strings.TrimSpace(string(body)))
+	return out, nil
+}
+
+// repairPrompt — інструкція для переписування тест-кейсів, які не пройшли лінтер.
+const repairPrompt = `You are a senior QA engineer reviewing generated test cases. The test cases below failed a quality check.
+Rewrite EACH of them to fix the listed problems while keeping what it verifies.
+- Steps must be concrete actions starting with a verb and naming the exact screen, button, field or text ("Click the 'Save' button"), never "Open the affected screen" or "Observe the result".
+- Add at least one precondition.
+- Expected and actual results must differ: expected = correct behaviour, actual = the bug. Cases with a category other than "reproduction" have not been run yet: keep their "actualResult" empty.
+- All text in English only.
+Return STRICT JSON ONLY (no markdown), the same number of test cases in the same order:
+{"testCases": [{"title": "string", "preconditions": ["string"], "steps": ["string"], "expectedResult": "string", "actualResult": "string"}]}
+
+Test cases and their problems:
+`
+
+// repairTestCases переписує тест-кейси з низькою оцінкою лінтера одним повторним запитом.
+// Переписаний кейс приймається, лише якщо його оцінка вища; про кейси, що так і лишились слабкими, додається примітка.
+func (a *OllamaAnalyzer) repairTestCases(ctx context.Context, res *BugAnalysis) {
+	if a.repairBelow <= 0 || res == nil {
+		return
+	}
+	var failing []int
+	var results []LintResult
+	for i, tc := range res.TestCases {
+		lr := LintTestCase(tc)
+		if lr.Score < a.repairBelow {
+			failing = append(failing, i)
+			results = append(results, lr)
+		}
 	}
-	if genResp.Error != "" {
-		return nil, fmt.Errorf("ollama text error: %s", genResp.Error)
+	if len(failing) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(repairPrompt)
+	for n, i := range failing {
+		tc := res.TestCases[i]
+		caseJSON, _ := json.Marshal(map[string]any{
+			"title":          tc.Title,
+			"preconditions":  tc.Preconditions,
+			"steps":          tc.Steps,
+			"expectedResult": tc.Expected,
+			"actualResult":   tc.Actual,
+			"category":       tc.Category,
+		})
+		fmt.Fprintf(&sb, "%d) problems: %s\n%s\n", n+1, results[n].Summary(), caseJSON)
+	}
+	slog.InfoContext(ctx, "ollama: re-prompting test cases that failed the linter", "failing", len(failing), "total", len(res.TestCases))
+
+	var repaired []TestCase
+	if response, err := a.generate(ctx, ollamaGenerateRequest{Model: a.model, Prompt: sb.String()}); err != nil {
+		slog.WarnContext(ctx, "ollama: repair request failed", "err", err)
+	} else {
+		var dto ollamaAnalysisDTO
+		jsonText := extractJSON(ctx, response)
+		if err := json.Unmarshal([]byte(jsonText), &dto); err != nil || jsonText == "" {
+			slog.WarnContext(ctx, "ollama: repair response is not JSON", "err", err)
+		} else if len(dto.TestCases) != len(failing) {
+			slog.WarnContext(ctx, "ollama: repair returned a different number of test cases; ignoring", "got", len(dto.TestCases), "want", len(failing))
+		} else {
+			repaired = dto.toBugAnalysis().TestCases
+		}
+	}
+
+	for n, i := range failing {
+		best := results[n]
+		if repaired != nil {
+			tc := res.TestCases[i]
+			fix := repaired[n]
+			tc.Title, tc.Preconditions, tc.Steps, tc.Expected, tc.Actual = fix.Title, fix.Preconditions, fix.Steps, fix.Expected, fix.Actual
+			if lr := LintTestCase(tc); lr.Score > best.Score {
+				res.TestCases[i], best = tc, lr
+			}
+		}
+		if best.Score < a.repairBelow {
+			res.Notes = append(res.Notes, fmt.Sprintf("Test case %s may need a manual review: %s.", caseRef(res.TestCases[i]), best.Summary()))
+		}
 	}
+}
 
-	responseBody := stripMarkdownCodeBlock(genResp.Response)
-	jsonText := extractFirstJSONObject(responseBody)
+// reviewPrompt — інструкція для перевірки наявного набору тест-кейсів команди.
+const reviewPrompt = `You are a senior QA engineer reviewing an existing test suite written by a team.
+Find:
+1. WEAK cases: steps that are vague or not actionable, missing preconditions, expected results that cannot be checked, or cases that would not catch the reported bug.
+2. MISSING cases: behaviour that no existing case covers — first of all the reported bug itself, then related negative and boundary checks of the same feature. Never repeat an existing case.
+Return STRICT JSON ONLY in ENGLISH (no markdown, no explanations) with this schema:
+{
+  "summary": "string (1-2 sentences: how well the suite covers the bug or feature)",
+  "weakCases": [{"id": "string (id of the existing case, e.g. TC-01 or #3)", "problem": "string", "suggestion": "string (how to improve it)"}],
+  "testCases": [
+    {
+      "title": "string",
+      "preconditions": ["string"],
+      "steps": ["string"],
+      "expectedResult": "string",
+      "actualResult": "string (only for a case that reproduces the reported bug, otherwise empty)",
+      "priority": "High | Medium | Low",
+      "severity": "Critical | Major | Minor | Trivial",
+      "category": "reproduction | regression | negative | boundary"
+    }
+  ]
+}
+"testCases" holds ONLY the missing cases (at most 6). Steps must be concrete actions starting with a verb.
+`
 
-	// Внутрішній DTO; steps/preconditions приймає і рядок, і масив.
-	var dto struct {
-		BugTitle  string `json:"bugTitle"`
-		TestCases []struct {
-			ID            string          `json:"id"`
-			Title         string          `json:"title"`
-			Preconditions flexStringSlice `json:"preconditions"`
-			Steps         flexStringSlice `json:"steps"`
-			Expected      string          `json:"expectedResult"`
-			Actual        string          `json:"actualResult"`
-			Priority      string          `json:"priority"`
-			Severity      string          `json:"severity"`
-		} `json:"testCases"`
+// maxReviewCases — скільки наявних кейсів передається моделі; решта перевіряються лише лінтером.
+const maxReviewCases = 60
+
+// Review перевіряє наявні тест-кейси щодо звіту про баг і/або скріншота: слабкі кейси знаходять лінтер і модель,
+// відсутні пропонує модель. Без report і image модель шукає прогалини в самому наборі.
+func (a *OllamaAnalyzer) Review(ctx context.Context, existing []TestCase, report string, image []byte) (*CoverageReview, error) {
+	if len(existing) == 0 {
+		return nil, fmt.Errorf("no test cases to review")
+	}
+	out := LintReview(existing)
+
+	sent := existing
+	if len(sent) > maxReviewCases {
+		sent = sent[:maxReviewCases]
+		out.Notes = append(out.Notes, fmt.Sprintf("Only the first %d of %d test cases were reviewed by the AI; the rest were checked by the linter only.", maxReviewCases, len(existing)))
+	}
+	ids := make([]string, len(sent))
+	var sb strings.Builder
+	sb.WriteString(reviewPrompt)
+	sb.WriteString("\nExisting test cases:\n")
+	for i, tc := range sent {
+		ids[i] = tc.ID
+		if ids[i] == "" {
+			ids[i] = fmt.Sprintf("#%d", i+1)
+		}
+		caseJSON, _ := json.Marshal(map[string]any{
+			"id":             ids[i],
+			"title":          tc.Title,
+			"preconditions":  tc.Preconditions,
+			"steps":          tc.Steps,
+			"expectedResult": tc.Expected,
+		})
+		sb.Write(caseJSON)
+		sb.WriteString("\n")
+	}
+	if report = strings.TrimSpace(report); report != "" {
+		sb.WriteString("\nBug report from tester (may be in any language):\n" + report + "\n")
 	}
 
-	// Якщо модель не повернула JSON, використовуємо raw-текст як fallback.
-	if jsonText == "" {
-		log.Printf("ollama text: no JSON object detected, using raw response fallback")
-		return fallbackFromRaw(genResp.Response), nil
+	req := ollamaGenerateRequest{Model: a.model}
+	if len(image) > 0 {
+		prepared, _, err := prepareImageTraced(ctx, image)
+		if err != nil {
+			slog.WarnContext(ctx, "ollama: prepare review image failed, using original", "err", err)
+			prepared = image
+		}
+		req.Images = []string{base64.StdEncoding.EncodeToString(prepared)}
+		sb.WriteString("\nThe attached screenshot shows the bug.\n")
+	}
+	if report == "" && len(image) == 0 {
+		sb.WriteString("\nThere is no bug report: look for gaps in the suite itself.\n")
 	}
+	req.Prompt = sb.String()
 
-	if err := json.Unmarshal([]byte(jsonText), &dto); err != nil {
-		log.Printf("ollama text: failed to parse JSON, using raw response fallback: %v, json=%s", err, jsonText)
-		return fallbackFromRaw(genResp.Response), nil
+	response, err := a.generate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("review: %w", err)
+	}
+	jsonText := extractJSON(ctx, response)
+	var dto ollamaAnalysisDTO
+	var weak struct {
+		WeakCases []struct {
+			ID         string `json:"id"`
+			Problem    string `json:"problem"`
+			Suggestion string `json:"suggestion"`
+		} `json:"weakCases"`
+	}
+	if jsonText == "" || json.Unmarshal([]byte(jsonText), &dto) != nil || json.Unmarshal([]byte(jsonText), &weak) != nil {
+		slog.WarnContext(ctx, "ollama review: no valid JSON in response, using linter results only")
+		out.Notes = append(out.Notes, "The AI review returned no structured answer; only the linter checks are shown.")
+		return out, nil
+	}
+
+	out.Summary = strings.TrimSpace(dto.Summary)
+	for _, w := range weak.WeakCases {
+		idx := -1
+		for i, id := range ids {
+			if strings.EqualFold(strings.TrimSpace(w.ID), id) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 || strings.TrimSpace(w.Problem) == "" {
+			continue
+		}
+		out.addWeak(idx+1, existing[idx], strings.TrimSpace(w.Problem), strings.TrimSpace(w.Suggestion))
+	}
+
+	missing := dto.toBugAnalysis()
+	for _, tc := range missing.TestCases {
+		tc.ID, tc.Region = "", nil
+		if !containsSimilarTestCase(existing, tc) && !containsSimilarTestCase(out.Missing, tc) {
+			out.Missing = append(out.Missing, tc)
+		}
 	}
+	if len(out.Missing) > 0 {
+		wrapped := &BugAnalysis{TestCases: out.Missing}
+		a.normalizeScales(ctx, wrapped)
+		out.Notes = append(out.Notes, wrapped.Notes...)
+	}
+	return out, nil
+}
 
+// ollamaAnalysisDTO — відповідь моделі за JSON-схемою з промптів; steps/preconditions/tags приймають
+// і рядок, і масив (модель іноді ламає схему).
+type ollamaAnalysisDTO struct {
+	BugTitle    string `json:"bugTitle"`
+	Summary     string `json:"summary"`
+	Component   string `json:"component"`
+	Environment *struct {
+		OS         string `json:"os"`
+		Device     string `json:"device"`
+		AppVersion string `json:"appVersion"`
+	} `json:"environment"`
+	Reproducibility     string          `json:"reproducibility"`
+	Tags                flexStringSlice `json:"tags"`
+	RootCauseHypothesis string          `json:"rootCauseHypothesis"`
+	TestCases           []struct {
+		ID            string          `json:"id"`
+		Title         string          `json:"title"`
+		Preconditions flexStringSlice `json:"preconditions"`
+		Steps         flexStringSlice `json:"steps"`
+		Expected      string          `json:"expectedResult"`
+		Actual        string          `json:"actualResult"`
+		Priority      string          `json:"priority"`
+		Severity      string          `json:"severity"`
+		Category      string          `json:"category"`
+		Region        *Region         `json:"region"`
+	} `json:"testCases"`
+}
+
+func (dto *ollamaAnalysisDTO) toBugAnalysis() *BugAnalysis {
 	out := &BugAnalysis{
-		BugTitle: dto.BugTitle,
+		BugTitle:            dto.BugTitle,
+		Summary:             strings.TrimSpace(dto.Summary),
+		Component:           strings.TrimSpace(dto.Component),
+		Reproducibility:     NormalizeReproducibility(dto.Reproducibility),
+		Tags:                MergeTags(dto.Tags),
+		RootCauseHypothesis: strings.TrimSpace(dto.RootCauseHypothesis),
+	}
+	if out.Reproducibility == "Unknown" {
+		out.Reproducibility = ""
+	}
+	if e := dto.Environment; e != nil {
+		env := &Environment{OS: strings.TrimSpace(e.OS), Device: strings.TrimSpace(e.Device), AppVersion: strings.TrimSpace(e.AppVersion)}
+		if !env.IsEmpty() {
+			out.Environment = env
+		}
 	}
 	for _, tc := range dto.TestCases {
 		steps := []string(tc.Steps)
@@ -348,28 +752,13 @@ Bug description from tester:
 			Steps:         steps,
 			Expected:      tc.Expected,
 			Actual:        tc.Actual,
-			Priority:      tc.Priority,
-			Severity:      tc.Severity,
+			Priority:      Priority(tc.Priority),
+			Severity:      Severity(tc.Severity),
+			Category:      ParseCategory(tc.Category),
+			Region:        tc.Region.normalize(),
 		})
 	}
-	if out.BugTitle == "" {
-		out.BugTitle = "Bug found based on textual description"
-	}
-	if len(out.TestCases) == 0 {
-		out.TestCases = []TestCase{
-			{
-				ID:       "TC-001",
-				Title:    "Verify behaviour described in the bug report",
-				Steps:    []string{"Follow the steps from the tester description", "Observe the behaviour that should be fixed"},
-				Expected: "The application behaves according to the functional requirements",
-				Actual:   desc,
-				Priority: "Medium",
-				Severity: "Major",
-			},
-		}
-	}
-
-	return out, nil
+	return out
 }
 
 // flexStringSlice приймає з JSON як один рядок, так і масив рядків (модель іноді повертає "steps": "one step" замість масиву).
@@ -470,6 +859,7 @@ func extractFirstJSONObject(s string) string {
 
 // fallbackFromRaw створює базовий BugAnalysis, якщо модель не дотрималась JSON-контракту.
 func fallbackFromRaw(raw string) *BugAnalysis {
+	fallbacksTotal.Inc("raw")
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
 		raw = "Model returned an empty response."
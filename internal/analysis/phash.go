@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	phashSize    = 32 // розмір зменшеного зображення для DCT
+	phashLowFreq = 8  // беремо лише низькі частоти 8x8
+)
+
+// PerceptualHash рахує 64-бітний pHash зображення (DCT-метод): схожі картинки
+// (інше стиснення, розмір, дрібні зміни) дають хеші з малою відстанню Геммінга.
+func PerceptualHash(raw []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return 0, fmt.Errorf("decode image: %w", err)
+	}
+	return perceptualHashImage(img), nil
+}
+
+func perceptualHashImage(img image.Image) uint64 {
+	small := image.NewGray(image.Rect(0, 0, phashSize, phashSize))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var pixels [phashSize][phashSize]float64
+	for y := 0; y < phashSize; y++ {
+		for x := 0; x < phashSize; x++ {
+			pixels[y][x] = float64(small.GrayAt(x, y).Y)
+		}
+	}
+
+	// 2D DCT-II лише для потрібних низьких частот.
+	var coeffs []float64
+	for v := 0; v < phashLowFreq; v++ {
+		for u := 0; u < phashLowFreq; u++ {
+			var sum float64
+			for y := 0; y < phashSize; y++ {
+				cy := math.Cos(float64(2*y+1) * float64(v) * math.Pi / (2 * phashSize))
+				for x := 0; x < phashSize; x++ {
+					sum += pixels[y][x] * cy * math.Cos(float64(2*x+1)*float64(u)*math.Pi/(2*phashSize))
+				}
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	// Медіана без DC-компоненти (вона відповідає лише за загальну яскравість).
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// HashDistance — відстань Геммінга між двома pHash (0 — ідентичні, 64 — протилежні).
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
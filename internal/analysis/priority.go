@@ -0,0 +1,247 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"bugreportbot/internal/storage"
+)
+
+// Priority — бізнес-пріоритет тест-кейсу.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "High"
+	PriorityMedium Priority = "Medium"
+	PriorityLow    Priority = "Low"
+)
+
+// Severity — рівень впливу бага.
+type Severity string
+
+const (
+	SeverityCritical Severity = "Critical"
+	SeverityMajor    Severity = "Major"
+	SeverityMinor    Severity = "Minor"
+	SeverityTrivial  Severity = "Trivial"
+)
+
+// priorityRank — чим менше, тим вищий пріоритет.
+var priorityRank = map[Priority]int{PriorityHigh: 0, PriorityMedium: 1, PriorityLow: 2}
+
+// prioritySynonyms — що моделі повертають замість High / Medium / Low.
+var prioritySynonyms = map[string]Priority{
+	"high": PriorityHigh, "highest": PriorityHigh, "urgent": PriorityHigh, "immediate": PriorityHigh, "critical": PriorityHigh, "blocker": PriorityHigh,
+	"p0": PriorityHigh, "p1": PriorityHigh, "must": PriorityHigh, "must fix": PriorityHigh,
+	"medium": PriorityMedium, "normal": PriorityMedium, "moderate": PriorityMedium, "mid": PriorityMedium, "p2": PriorityMedium, "should": PriorityMedium,
+	"low": PriorityLow, "lowest": PriorityLow, "minor": PriorityLow, "trivial": PriorityLow, "p3": PriorityLow, "p4": PriorityLow, "p5": PriorityLow, "nice to have": PriorityLow, "could": PriorityLow,
+}
+
+// severitySynonyms — що моделі повертають замість Critical / Major / Minor / Trivial.
+var severitySynonyms = map[string]Severity{
+	"critical": SeverityCritical, "blocker": SeverityCritical, "blocking": SeverityCritical, "fatal": SeverityCritical, "crash": SeverityCritical, "showstopper": SeverityCritical,
+	"s0": SeverityCritical, "s1": SeverityCritical, "sev0": SeverityCritical, "sev1": SeverityCritical,
+	"major": SeverityMajor, "high": SeverityMajor, "serious": SeverityMajor, "severe": SeverityMajor, "s2": SeverityMajor, "sev2": SeverityMajor,
+	"minor": SeverityMinor, "medium": SeverityMinor, "moderate": SeverityMinor, "normal": SeverityMinor, "s3": SeverityMinor, "sev3": SeverityMinor,
+	"trivial": SeverityTrivial, "low": SeverityTrivial, "cosmetic": SeverityTrivial, "lowest": SeverityTrivial, "s4": SeverityTrivial, "sev4": SeverityTrivial,
+}
+
+// normalizeScaleValue готує сире значення до пошуку у словнику: регістр, пробіли, "P1 - Urgent" → "p1".
+func normalizeScaleValue(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	for _, sep := range []string{" - ", " — ", "(", "/", ":"} {
+		if i := strings.Index(v, sep); i > 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+	}
+	v = strings.TrimPrefix(v, "severity ")
+	v = strings.TrimPrefix(v, "priority ")
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// ParsePriority розпізнає пріоритет: синоніми, будь-який регістр і числову шкалу (1 — найвищий).
+func ParsePriority(v string) (Priority, bool) {
+	key := normalizeScaleValue(v)
+	if p, ok := prioritySynonyms[key]; ok {
+		return p, true
+	}
+	if n, err := strconv.Atoi(key); err == nil {
+		switch {
+		case n <= 1:
+			return PriorityHigh, true
+		case n == 2:
+			return PriorityMedium, true
+		default:
+			return PriorityLow, true
+		}
+	}
+	return "", false
+}
+
+// ParseSeverity розпізнає критичність: синоніми, будь-який регістр і числову шкалу (1 — найкритичніший).
+func ParseSeverity(v string) (Severity, bool) {
+	key := normalizeScaleValue(v)
+	if s, ok := severitySynonyms[key]; ok {
+		return s, true
+	}
+	if n, err := strconv.Atoi(key); err == nil {
+		switch {
+		case n <= 1:
+			return SeverityCritical, true
+		case n == 2:
+			return SeverityMajor, true
+		case n == 3:
+			return SeverityMinor, true
+		default:
+			return SeverityTrivial, true
+		}
+	}
+	return "", false
+}
+
+// priorityForSeverity — пріоритет за замовчуванням, коли модель вказала лише критичність.
+var priorityForSeverity = map[Severity]Priority{
+	SeverityCritical: PriorityHigh,
+	SeverityMajor:    PriorityMedium,
+	SeverityMinor:    PriorityLow,
+	SeverityTrivial:  PriorityLow,
+}
+
+// ScaleRules — налаштування шкал для проєкту: власні значення (наприклад, "Showstopper" → Critical)
+// і винятки з правил узгодженості.
+type ScaleRules struct {
+	// Priority і Severity — додаткові синоніми (ключ без урахування регістру) поверх вбудованих.
+	Priority map[string]Priority `json:"priority,omitempty"`
+	Severity map[string]Severity `json:"severity,omitempty"`
+	// AllowCriticalLowPriority дозволяє Critical-бага мати Low пріоритет (наприклад, для застарілих функцій).
+	AllowCriticalLowPriority bool `json:"allowCriticalLowPriority,omitempty"`
+}
+
+// ScaleConfig — вміст файла з правилами: загальні правила та правила окремих проєктів.
+type ScaleConfig struct {
+	Default  ScaleRules            `json:"default"`
+	Projects map[string]ScaleRules `json:"projects,omitempty"`
+}
+
+// LoadScaleConfig читає правила з JSON-файла (відсутній файл — лише вбудовані синоніми).
+func LoadScaleConfig(path string) (*ScaleConfig, error) {
+	var c ScaleConfig
+	if err := storage.LoadJSON(path, &c); err != nil {
+		return nil, fmt.Errorf("load priority rules: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("priority rules %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *ScaleConfig) validate() error {
+	check := func(where string, r ScaleRules) error {
+		for k, p := range r.Priority {
+			if _, ok := priorityRank[p]; !ok {
+				return fmt.Errorf("%s: priority %q maps to unknown value %q (use High, Medium or Low)", where, k, p)
+			}
+		}
+		for k, s := range r.Severity {
+			if _, ok := priorityForSeverity[s]; !ok {
+				return fmt.Errorf("%s: severity %q maps to unknown value %q (use Critical, Major, Minor or Trivial)", where, k, s)
+			}
+		}
+		return nil
+	}
+	if err := check("default", c.Default); err != nil {
+		return err
+	}
+	for name, r := range c.Projects {
+		if err := check("project "+name, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rulesFor поєднує загальні правила з правилами проєкту (назва без урахування регістру).
+func (c *ScaleConfig) rulesFor(project string) ScaleRules {
+	if c == nil {
+		return ScaleRules{}
+	}
+	out := ScaleRules{
+		Priority:                 make(map[string]Priority),
+		Severity:                 make(map[string]Severity),
+		AllowCriticalLowPriority: c.Default.AllowCriticalLowPriority,
+	}
+	add := func(r ScaleRules) {
+		for k, v := range r.Priority {
+			out.Priority[normalizeScaleValue(k)] = v
+		}
+		for k, v := range r.Severity {
+			out.Severity[normalizeScaleValue(k)] = v
+		}
+	}
+	add(c.Default)
+	for name, r := range c.Projects {
+		if strings.EqualFold(name, project) {
+			add(r)
+			out.AllowCriticalLowPriority = out.AllowCriticalLowPriority || r.AllowCriticalLowPriority
+		}
+	}
+	return out
+}
+
+// NormalizeScales приводить Priority/Severity усіх тест-кейсів до канонічних значень за правилами проєкту,
+// заповнює порожні значення та виправляє неузгоджені пари. Про кожне виправлення додається примітка.
+func NormalizeScales(res *BugAnalysis, c *ScaleConfig, project string) {
+	if res == nil {
+		return
+	}
+	rules := c.rulesFor(project)
+	for i := range res.TestCases {
+		tc := &res.TestCases[i]
+		rawP, rawS := string(tc.Priority), string(tc.Severity)
+
+		sev, ok := rules.Severity[normalizeScaleValue(rawS)]
+		if !ok {
+			sev, ok = ParseSeverity(rawS)
+		}
+		if !ok {
+			if rawS != "" {
+				slog.Debug("unknown severity, using default", "severity", rawS, "default", SeverityMajor)
+			}
+			sev = SeverityMajor
+		}
+
+		prio, ok := rules.Priority[normalizeScaleValue(rawP)]
+		if !ok {
+			prio, ok = ParsePriority(rawP)
+		}
+		if !ok {
+			if rawP != "" {
+				slog.Debug("unknown priority, deriving it from severity", "priority", rawP)
+			}
+			prio = priorityForSeverity[sev]
+		}
+
+		if sev == SeverityCritical && prio == PriorityLow && !rules.AllowCriticalLowPriority {
+			prio = PriorityMedium
+			res.Notes = append(res.Notes, fmt.Sprintf("Test case %s: priority raised from Low to Medium because severity is Critical.", caseRef(*tc)))
+		}
+
+		tc.Priority, tc.Severity = prio, sev
+	}
+}
+
+type projectKey struct{}
+
+// WithProject передає назву проєкту в аналізатор (для правил пріоритетів тощо).
+func WithProject(ctx context.Context, project string) context.Context {
+	return context.WithValue(ctx, projectKey{}, project)
+}
+
+// ProjectFromContext повертає назву проєкту, передану через WithProject, або "".
+func ProjectFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(projectKey{}).(string)
+	return v
+}
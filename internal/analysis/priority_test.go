@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeScalesNotesNameTheTestCase(t *testing.T) {
+	res := &BugAnalysis{TestCases: []TestCase{
+		{Title: "Open the cart", Priority: "High", Severity: "Minor"},
+		{Title: "Pay with an expired card", Priority: "low", Severity: "blocker"},
+	}}
+	NormalizeScales(res, nil, "")
+
+	if got := res.TestCases[1]; got.Priority != PriorityMedium || got.Severity != SeverityCritical {
+		t.Errorf("case 2 = %s/%s, want Medium/Critical", got.Priority, got.Severity)
+	}
+	if len(res.Notes) != 1 {
+		t.Fatalf("notes = %q, want one", res.Notes)
+	}
+	// Примітка не повинна залежати від позиції кейсу: бот потім об'єднує результати і перенумеровує кейси.
+	if note := res.Notes[0]; !strings.Contains(note, `"Pay with an expired card"`) || strings.Contains(note, "#") {
+		t.Errorf("note = %q, want it to name the test case by title", note)
+	}
+}
+
+func TestCaseRef(t *testing.T) {
+	tests := []struct {
+		tc   TestCase
+		want string
+	}{
+		{TestCase{ID: "TC-003", Title: "Sign in\nwith  Google"}, `"Sign in with Google"`},
+		{TestCase{Steps: []string{"Open the app", "Tap Login"}}, `starting with "Open the app"`},
+		{TestCase{}, "without a title"},
+	}
+	for _, tt := range tests {
+		if got := caseRef(tt.tc); got != tt.want {
+			t.Errorf("caseRef(%+v) = %q, want %q", tt.tc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Aspect — складова повноцінного баг-репорту.
+type Aspect string
+
+const (
+	AspectScreen   Aspect = "screen"
+	AspectAction   Aspect = "action"
+	AspectExpected Aspect = "expected"
+	AspectActual   Aspect = "actual"
+)
+
+// aspectOrder — порядок, у якому ставляться уточнювальні питання.
+var aspectOrder = []Aspect{AspectActual, AspectScreen, AspectAction, AspectExpected}
+
+// aspectKeywords — корені слів (англ./укр./рос.), за якими видно, що аспект згадано.
+var aspectKeywords = map[Aspect][]string{
+	AspectScreen: {
+		"screen", "page", "form", "button", "tab", "menu", "dialog", "modal", "window", "field", "popup", "settings", "login", "checkout", "cart", "profile", " app ",
+		"екран", "сторінк", "форм", "кнопк", "вкладк", "меню", "діалог", "вікн", "поле", "налаштуван", "кошик", "профіл", "додат",
+		"экран", "страниц", "окн", "настройк", "корзин", "прилож",
+	},
+	AspectAction: {
+		"click", "tap", "press", "open", "enter", "type", "submit", "select", "navigate", "scroll", "upload", "save", "log in", "sign in", "go to", "choose",
+		"натис", "відкри", "ввод", "ввів", "ввела", "вибра", "перейш", "зберег", "завантаж", "прокру", "тисн",
+		"нажим", "нажал", "открыл", "ввел", "выбрал", "перешел", "сохран", "загруз",
+	},
+	AspectExpected: {
+		"expect", "should", "must", "supposed", "instead", "correct", "normally",
+		"очікув", "має ", "мав ", "мала ", "повин", "замість", "нормально", "правильн",
+		"ожида", "должн", "вместо",
+	},
+	AspectActual: {
+		" but ", "nothing", "error", "crash", "doesn't", "does not", "not ", "fail", "broken", "freeze", "hang", "wrong", "missing", "cut off", "overlap", "blank", "disappear", "bug",
+		"але", "нічого", "помилк", "не ", "зависа", "вилітає", "зламан", "зника", "обріза", "неправильн", "відсутн",
+		"но ", "ничего", "ошибк", "завис", "вылета", "сломан", "исчеза", "обреза",
+	},
+}
+
+// aspectQuestions — уточнювальне питання для аспекту, якого бракує.
+var aspectQuestions = map[Aspect]string{
+	AspectScreen:   "Which screen, page or feature were you on?",
+	AspectAction:   "What exactly did you do right before the problem (the steps)?",
+	AspectExpected: "What did you expect to happen?",
+	AspectActual:   "What actually happened (error text, what you saw on the screen)?",
+}
+
+// DescriptionAssessment — оцінка якості текстового опису бага.
+type DescriptionAssessment struct {
+	// Score — 0..1: частка знайдених аспектів з невеликою вагою за довжину, помножена на Readable.
+	Score float64
+	// Language — "en", "uk", "ru" або "" (не вдалося визначити).
+	Language string
+	// Readable — 0..1: частка слів, схожих на англійські, українські чи російські. Текст мовою, яку не вдалося
+	// визначити, або набір літер ("asdfghjkl") дає низьку частку, і такий опис не вважається достатнім.
+	Readable float64
+	Present  []Aspect
+	Missing  []Aspect
+	// LowSignal — у тексті майже немає слів (".", "??", "ok") — аналізувати нема чого.
+	LowSignal bool
+	// Sufficient — опису достатньо, щоб генерувати тест-кейси.
+	Sufficient bool
+}
+
+// Questions повертає до max уточнювальних питань про відсутні аспекти.
+func (d DescriptionAssessment) Questions(max int) []string {
+	var out []string
+	for _, a := range d.Missing {
+		if len(out) == max {
+			break
+		}
+		out = append(out, aspectQuestions[a])
+	}
+	return out
+}
+
+const (
+	// minLetters — менше літер, ніж це, вважається шумом (QA_SUMMARY Issue 1: одна крапка).
+	minLetters = 3
+	// longDescriptionWords — довгі описи приймаються навіть без усіх ключових слів.
+	longDescriptionWords = 25
+	// minReadable — менша частка читабельних слів означає, що текст не схожий на опис жодною з мов бота.
+	minReadable = 0.5
+	// maxConsonantRun — стільки приголосних поспіль уже не трапляється у звичайних словах ("asdfghjkl").
+	maxConsonantRun = 6
+)
+
+// AssessDescription оцінює опис бага: довжину, мову та наявність екрана, дії, очікування і фактичного результату.
+func AssessDescription(text string) DescriptionAssessment {
+	var d DescriptionAssessment
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	d.Language = DetectLanguage(text)
+	if letters < minLetters {
+		d.LowSignal = true
+		d.Missing = aspectOrder
+		return d
+	}
+
+	lower := " " + strings.ToLower(text) + " "
+	for _, a := range aspectOrder {
+		found := false
+		for _, kw := range aspectKeywords[a] {
+			if strings.Contains(lower, kw) {
+				found = true
+				break
+			}
+		}
+		if found {
+			d.Present = append(d.Present, a)
+		} else {
+			d.Missing = append(d.Missing, a)
+		}
+	}
+
+	words := len(strings.Fields(text))
+	lengthBonus := float64(words) / longDescriptionWords
+	if lengthBonus > 1 {
+		lengthBonus = 1
+	}
+	d.Readable = readableShare(text, d.Language)
+	d.Score = d.Readable * (0.8*float64(len(d.Present))/float64(len(aspectOrder)) + 0.2*lengthBonus)
+	d.Sufficient = d.Readable >= minReadable && (words >= longDescriptionWords || (words >= 4 && len(d.Present) >= 2))
+	return d
+}
+
+// vowels — голосні латиниці й кирилиці: слово без жодної з них не схоже на слово.
+const vowels = "aeiouyаеєиіїоуюяыэё"
+
+// readableShare повертає частку слів тексту, схожих на слова мови lang; слова без літер (числа, версії) не враховуються.
+// Якщо мову не визначено, читабельних слів немає.
+func readableShare(text, lang string) float64 {
+	if lang == "" {
+		return 0
+	}
+	total, readable := 0, 0
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		total++
+		if looksLikeWord(w) {
+			readable++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(readable) / float64(total)
+}
+
+// looksLikeWord — слово латиницею чи кирилицею з голосною і без довгого ряду приголосних.
+// Короткі слова без голосних ("I", "в", "з", "UI") теж читабельні.
+func looksLikeWord(w string) bool {
+	hasVowel, run, letters := false, 0, 0
+	for _, r := range w {
+		if !(r >= 'a' && r <= 'z') && !unicode.Is(unicode.Cyrillic, r) {
+			return false
+		}
+		letters++
+		if strings.ContainsRune(vowels, r) {
+			hasVowel, run = true, 0
+			continue
+		}
+		if run++; run >= maxConsonantRun {
+			return false
+		}
+	}
+	return hasVowel || letters <= 3
+}
+
+// DetectLanguage грубо визначає мову за алфавітом і характерними літерами: "en", "uk", "ru" або "".
+func DetectLanguage(text string) string {
+	var latin, cyrillic, ukOnly, ruOnly int
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+			switch r {
+			case 'і', 'ї', 'є', 'ґ':
+				ukOnly++
+			case 'ы', 'э', 'ё', 'ъ':
+				ruOnly++
+			}
+		}
+	}
+	switch {
+	case latin == 0 && cyrillic == 0:
+		return ""
+	case latin >= cyrillic:
+		return "en"
+	case ruOnly > ukOnly:
+		return "ru"
+	default:
+		return "uk"
+	}
+}
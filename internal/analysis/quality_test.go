@@ -0,0 +1,38 @@
+package analysis
+
+import "testing"
+
+func TestAssessDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		lang        string
+		sufficient  bool
+		lowSignal   bool
+		minScore    float64
+		maxScore    float64
+		minReadable float64
+	}{
+		{name: "dot", text: ".", lowSignal: true},
+		{name: "english", text: "On the login screen I tap Login but nothing happens, expected the dashboard to open", lang: "en", sufficient: true, minScore: 0.8, maxScore: 1, minReadable: 1},
+		{name: "ukrainian", text: "На екрані входу натискаю кнопку Увійти, але нічого не відбувається", lang: "uk", sufficient: true, minScore: 0.5, maxScore: 1, minReadable: 1},
+		{name: "russian", text: "На экране входа нажимаю кнопку, но ничего не происходит", lang: "ru", sufficient: true, minScore: 0.5, maxScore: 1, minReadable: 1},
+		{name: "version numbers do not count", text: "App 2.14.0 crashes on the settings screen after I tap Save", lang: "en", sufficient: true, minScore: 0.5, maxScore: 1, minReadable: 1},
+		{name: "undetected language", text: "登录按钮 不工作 点击后 没有反应 错误", lang: "", maxScore: 0},
+		{name: "keyboard mash", text: "asdfghjkl qwrtpsdfg zxcvbnmlk button error", lang: "en", maxScore: 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := AssessDescription(tt.text)
+			if d.Language != tt.lang || d.Sufficient != tt.sufficient || d.LowSignal != tt.lowSignal {
+				t.Errorf("lang/sufficient/lowSignal = %q/%v/%v, want %q/%v/%v", d.Language, d.Sufficient, d.LowSignal, tt.lang, tt.sufficient, tt.lowSignal)
+			}
+			if d.Score < tt.minScore || d.Score > tt.maxScore {
+				t.Errorf("score = %.2f, want %.2f..%.2f", d.Score, tt.minScore, tt.maxScore)
+			}
+			if d.Readable < tt.minReadable {
+				t.Errorf("readable = %.2f, want at least %.2f", d.Readable, tt.minReadable)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrQuotaExceeded — аналіз не запускався: перевірка лімітів перед викликом моделі відмовила (див. WithModelCallGate).
+var ErrQuotaExceeded = errors.New("analysis quota exceeded")
+
+type modelCallGateKey struct{}
+
+// WithModelCallGate додає перевірку, яку аналізатор викликає один раз безпосередньо перед зверненням до моделі.
+// Результат з кешу модель не викликає, тож і ліміти не витрачає. Якщо gate повертає false, аналіз завершується ErrQuotaExceeded.
+func WithModelCallGate(ctx context.Context, gate func() bool) context.Context {
+	return context.WithValue(ctx, modelCallGateKey{}, gate)
+}
+
+// allowModelCall виконує перевірку з WithModelCallGate (якщо вона є).
+func allowModelCall(ctx context.Context) error {
+	if gate, ok := ctx.Value(modelCallGateKey{}).(func() bool); ok && !gate() {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
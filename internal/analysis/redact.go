@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/draw"
+
+	"bugreportbot/internal/pii"
+)
+
+// Redaction — одна замазана ділянка скріншота. Саме значення не зберігається, лише тип і місце.
+type Redaction struct {
+	Kind   pii.Kind `json:"kind"`
+	Region Region   `json:"region"`
+}
+
+// Redactor знаходить на скріншоті чутливі дані (через OCR + регулярні детектори) і розмиває їх
+// до того, як зображення потрапить у модель, історію чи трекер.
+type Redactor struct {
+	ocr       OCR
+	detectors []pii.Detector
+}
+
+func NewRedactor(ocr OCR) *Redactor {
+	return &Redactor{
+		ocr:       ocr,
+		detectors: pii.DefaultDetectors(),
+	}
+}
+
+// Redact повертає зображення з розмитими чутливими ділянками (PNG) і список того, що було замазано.
+// Якщо нічого не знайдено, повертає оригінальні байти без перекодування.
+func (r *Redactor) Redact(ctx context.Context, raw []byte) ([]byte, []Redaction, error) {
+	boxes, err := r.ocr.Recognize(ctx, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ocr for redaction: %w", err)
+	}
+	redactions := FindSensitiveRegions(boxes, r.detectors)
+	if len(redactions) == 0 {
+		return raw, nil, nil
+	}
+
+	img, err := decodeImage(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	for _, rd := range redactions {
+		pixelate(dst, rd.Region)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, dst); err != nil {
+		return nil, nil, fmt.Errorf("encode png: %w", err)
+	}
+	if c, ok := r.ocr.(*CachingOCR); ok {
+		// Аналізатор отримає вже відредагований скріншот: даємо йому той самий текст, але без чутливих даних.
+		c.Remember(out.Bytes(), maskBoxes(boxes, r.detectors))
+	}
+	return out.Bytes(), redactions, nil
+}
+
+// maskBoxes замінює знайдені в рядках чутливі дані на [KIND], як pii.Scrubber.
+func maskBoxes(boxes []TextBox, detectors []pii.Detector) []TextBox {
+	out := make([]TextBox, len(boxes))
+	for i, tb := range boxes {
+		out[i] = tb
+		matches := pii.Find(tb.Text, detectors)
+		if len(matches) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		last := 0
+		for _, m := range matches {
+			sb.WriteString(tb.Text[last:m.Start])
+			sb.WriteString("[" + strings.ToUpper(string(m.Kind)) + "]")
+			last = m.End
+		}
+		sb.WriteString(tb.Text[last:])
+		out[i].Text = sb.String()
+	}
+	return out
+}
+
+// redactPadding — запас навколо знайденого фрагмента (частка висоти рядка), щоб не лишались краї символів.
+const redactPadding = 0.3
+
+// FindSensitiveRegions шукає чутливі дані в OCR-рядках. Позиція фрагмента всередині рядка оцінюється
+// пропорційно до позиції символів (моноширинне наближення) з невеликим запасом.
+func FindSensitiveRegions(boxes []TextBox, detectors []pii.Detector) []Redaction {
+	var out []Redaction
+	for _, tb := range boxes {
+		runes := []rune(tb.Text)
+		if len(runes) == 0 {
+			continue
+		}
+		for _, m := range pii.Find(tb.Text, detectors) {
+			start := len([]rune(tb.Text[:m.Start]))
+			end := len([]rune(tb.Text[:m.End]))
+			charW := tb.Region.Width / float64(len(runes))
+			padX := tb.Region.Height * redactPadding
+			region := Region{
+				X:      tb.Region.X + float64(start)*charW - padX,
+				Y:      tb.Region.Y - tb.Region.Height*redactPadding/2,
+				Width:  float64(end-start)*charW + 2*padX,
+				Height: tb.Region.Height * (1 + redactPadding),
+			}
+			if nr := region.normalize(); nr != nil {
+				out = append(out, Redaction{Kind: m.Kind, Region: *nr})
+			}
+		}
+	}
+	return out
+}
+
+// pixelate замінює ділянку великими однотонними блоками — на відміну від легкого blur, текст не відновлюється.
+func pixelate(img *image.RGBA, r Region) {
+	b := img.Bounds()
+	rect := image.Rect(
+		int(r.X*float64(b.Dx())), int(r.Y*float64(b.Dy())),
+		int((r.X+r.Width)*float64(b.Dx())), int((r.Y+r.Height)*float64(b.Dy())),
+	).Intersect(b)
+	if rect.Empty() {
+		return
+	}
+	block := rect.Dy() / 2
+	if block < 4 {
+		block = 4
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y += block {
+		for x := rect.Min.X; x < rect.Max.X; x += block {
+			cell := image.Rect(x, y, x+block, y+block).Intersect(rect)
+			draw.Draw(img, cell, image.NewUniform(averageColor(img, cell)), image.Point{}, draw.Src)
+		}
+	}
+}
+
+func averageColor(img *image.RGBA, r image.Rectangle) color.RGBA {
+	var sr, sg, sb, n uint64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			sr += uint64(c.R)
+			sg += uint64(c.G)
+			sb += uint64(c.B)
+			n++
+		}
+	}
+	if n == 0 {
+		return color.RGBA{A: 255}
+	}
+	return color.RGBA{R: uint8(sr / n), G: uint8(sg / n), B: uint8(sb / n), A: 255}
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"bugreportbot/internal/pii"
+)
+
+func TestFindSensitiveRegions(t *testing.T) {
+	line := Region{X: 0.1, Y: 0.5, Width: 0.44, Height: 0.05}
+	tests := []struct {
+		name string
+		box  TextBox
+		want []Redaction
+	}{
+		{name: "no pii", box: TextBox{Text: "Sign in with Google", Region: line}},
+		{name: "empty line", box: TextBox{Region: line}},
+		{
+			// 22 символи на 0.44 ширини — 0.02 на символ; e-mail займає символи 7..22, запас — 0.3 висоти рядка.
+			name: "email inside the line",
+			box:  TextBox{Text: "Email: ann@example.com", Region: line},
+			want: []Redaction{{Kind: pii.Email, Region: Region{X: 0.225, Y: 0.4925, Width: 0.33, Height: 0.065}}},
+		},
+		{
+			// Позиція рахується в символах, а не в байтах UTF-8.
+			name: "cyrillic prefix",
+			box:  TextBox{Text: "Пошта: ann@example.com", Region: line},
+			want: []Redaction{{Kind: pii.Email, Region: Region{X: 0.225, Y: 0.4925, Width: 0.33, Height: 0.065}}},
+		},
+		{
+			name: "clamped to the image",
+			box:  TextBox{Text: "ann@example.com", Region: Region{X: 0, Y: 0, Width: 0.3, Height: 0.05}},
+			want: []Redaction{{Kind: pii.Email, Region: Region{X: 0, Y: 0, Width: 0.315, Height: 0.0575}}},
+		},
+		{
+			name: "several matches",
+			box:  TextBox{Text: "ann@example.com +380 67 123 45 67", Region: line},
+			want: []Redaction{{Kind: pii.Email}, {Kind: pii.Phone}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindSensitiveRegions([]TextBox{tt.box}, pii.DefaultDetectors())
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				if got[i].Kind != w.Kind {
+					t.Errorf("redaction %d: kind %q, want %q", i, got[i].Kind, w.Kind)
+				}
+				if w.Region != (Region{}) && !regionNear(got[i].Region, w.Region) {
+					t.Errorf("redaction %d: region %+v, want %+v", i, got[i].Region, w.Region)
+				}
+			}
+		})
+	}
+}
+
+func regionNear(a, b Region) bool {
+	const eps = 1e-9
+	return math.Abs(a.X-b.X) < eps && math.Abs(a.Y-b.Y) < eps &&
+		math.Abs(a.Width-b.Width) < eps && math.Abs(a.Height-b.Height) < eps
+}
+
+func TestMaskBoxes(t *testing.T) {
+	line := Region{X: 0.1, Y: 0.2, Width: 0.5, Height: 0.05}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Order total: 120 UAH", "Order total: 120 UAH"},
+		{"Call +380 67 123 45 67 or ann@example.com", "Call [PHONE] or [EMAIL]"},
+		{"Card 4111 1111 1111 1111 expired", "Card [CARD] expired"},
+		{"Карта 4111 1111 1111 1112 недійсна", "Карта 4111 1111 1111 1112 недійсна"}, // не проходить перевірку Луна
+	}
+	for _, tt := range tests {
+		got := maskBoxes([]TextBox{{Text: tt.text, Region: line}}, pii.DefaultDetectors())
+		if len(got) != 1 || got[0].Text != tt.want || got[0].Region != line {
+			t.Errorf("maskBoxes(%q) = %+v, want %q in the same region", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRedactorRedact(t *testing.T) {
+	// Вертикальні смуги: після пікселізації чорно-білий візерунок усередині ділянки стає сірим.
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := color.RGBA{A: 255}
+			if x%2 == 0 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+
+	t.Run("nothing found", func(t *testing.T) {
+		r := &Redactor{ocr: &fakeOCR{boxes: []TextBox{{Text: "Sign in", Region: Region{Width: 0.5, Height: 0.1}}}}, detectors: pii.DefaultDetectors()}
+		out, redactions, err := r.Redact(context.Background(), raw)
+		if err != nil || redactions != nil || !bytes.Equal(out, raw) {
+			t.Fatalf("got %d bytes, %+v, %v; want the original image untouched", len(out), redactions, err)
+		}
+	})
+
+	t.Run("email pixelated", func(t *testing.T) {
+		box := TextBox{Text: "ann@example.com", Region: Region{X: 0.25, Y: 0.4, Width: 0.5, Height: 0.2}}
+		r := &Redactor{ocr: &fakeOCR{boxes: []TextBox{box}}, detectors: pii.DefaultDetectors()}
+		out, redactions, err := r.Redact(context.Background(), raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(redactions) != 1 || redactions[0].Kind != pii.Email {
+			t.Fatalf("redactions = %+v, want one email", redactions)
+		}
+		decoded, err := png.Decode(bytes.NewReader(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		inside := color.RGBAModel.Convert(decoded.At(100, 50)).(color.RGBA)
+		if inside.R == 0 || inside.R == 255 {
+			t.Errorf("pixel inside the redaction = %v, want a blended grey", inside)
+		}
+		for _, p := range []image.Point{{10, 50}, {100, 5}, {190, 95}} {
+			if got, want := color.RGBAModel.Convert(decoded.At(p.X, p.Y)), img.At(p.X, p.Y); got != want {
+				t.Errorf("pixel %v outside the redaction changed: %v, want %v", p, got, want)
+			}
+		}
+	})
+}
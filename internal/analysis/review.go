@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reviewMinScore — наявні кейси з оцінкою лінтера нижче цього значення вважаються слабкими.
+const reviewMinScore = 0.7
+
+// CoverageReview — результат перевірки наявного набору тест-кейсів команди щодо звіту про баг або скріншота.
+type CoverageReview struct {
+	Summary string
+	// Weak — наявні кейси, які варто посилити.
+	Weak []WeakTestCase
+	// Missing — запропоновані нові кейси для того, що набір не покриває.
+	Missing []TestCase
+	Notes   []string
+}
+
+// WeakTestCase — наявний кейс з переліком проблем.
+type WeakTestCase struct {
+	// Index — номер кейсу в наборі, починаючи з 1.
+	Index      int
+	ID         string
+	Title      string
+	Problems   []string
+	Suggestion string
+}
+
+// Reviewer — аналізатор, що вміє перевірити наявні тест-кейси: знайти слабкі й запропонувати відсутні.
+// report і image — звіт про баг і скріншот, щодо яких перевіряється набір (обидва можуть бути порожні).
+type Reviewer interface {
+	Review(ctx context.Context, existing []TestCase, report string, image []byte) (*CoverageReview, error)
+}
+
+// LintReview перевіряє набір лише лінтером, без моделі: знаходить слабкі кейси, але не відсутні.
+func LintReview(existing []TestCase) *CoverageReview {
+	r := &CoverageReview{}
+	for i, tc := range existing {
+		lr := lintExisting(tc)
+		if lr.Score >= reviewMinScore {
+			continue
+		}
+		w := WeakTestCase{Index: i + 1, ID: tc.ID, Title: tc.Title}
+		for _, is := range lr.Issues {
+			w.Problems = append(w.Problems, is.Message)
+		}
+		r.Weak = append(r.Weak, w)
+	}
+	return r
+}
+
+// addWeak додає проблему до кейсу з номером index (з 1), об'єднуючи її з уже знайденими лінтером.
+func (r *CoverageReview) addWeak(index int, tc TestCase, problem, suggestion string) {
+	for i := range r.Weak {
+		if r.Weak[i].Index == index {
+			r.Weak[i].Problems = append(r.Weak[i].Problems, problem)
+			if r.Weak[i].Suggestion == "" {
+				r.Weak[i].Suggestion = suggestion
+			}
+			return
+		}
+	}
+	r.Weak = append(r.Weak, WeakTestCase{Index: index, ID: tc.ID, Title: tc.Title, Problems: []string{problem}, Suggestion: suggestion})
+	sort.Slice(r.Weak, func(i, j int) bool { return r.Weak[i].Index < r.Weak[j].Index })
+}
+
+// lintExisting — лінтер для кейсів команди: у наборі тест-кейсів фактичного результату зазвичай немає,
+// тож порожній Actual не є вадою.
+func lintExisting(tc TestCase) LintResult {
+	if strings.TrimSpace(tc.Actual) == "" && tc.Category == "" {
+		tc.Category = CategoryRegression
+	}
+	return LintTestCase(tc)
+}
+
+// MapText застосовує fn до всіх текстових полів огляду (наприклад, для маскування персональних даних).
+func (r *CoverageReview) MapText(fn func(string) string) {
+	if r == nil || fn == nil {
+		return
+	}
+	r.Summary = fn(r.Summary)
+	for i := range r.Weak {
+		w := &r.Weak[i]
+		w.Title, w.Suggestion = fn(w.Title), fn(w.Suggestion)
+		for j := range w.Problems {
+			w.Problems[j] = fn(w.Problems[j])
+		}
+	}
+	missing := &BugAnalysis{TestCases: r.Missing}
+	missing.MapText(fn)
+	for i := range r.Notes {
+		r.Notes[i] = fn(r.Notes[i])
+	}
+}
+
+// FormatCoverageReview перетворює огляд у повідомлення; total — кількість кейсів у наборі.
+// Запропоновані кейси тут лише згадуються: бот надсилає їх окремо як звичайний результат.
+func FormatCoverageReview(r *CoverageReview, total int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Test suite review: %d test cases\n\n", total))
+	if r.Summary != "" {
+		b.WriteString(r.Summary)
+		b.WriteString("\n\n")
+	}
+
+	if len(r.Weak) == 0 {
+		b.WriteString("No weak test cases found.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("Weak test cases (%d):\n", len(r.Weak)))
+		for _, w := range r.Weak {
+			name := w.ID
+			if name == "" {
+				name = fmt.Sprintf("#%d", w.Index)
+			}
+			if w.Title != "" {
+				name += " — " + w.Title
+			}
+			b.WriteString("• " + name + "\n")
+			if len(w.Problems) > 0 {
+				b.WriteString("  Problems: " + strings.Join(w.Problems, "; ") + "\n")
+			}
+			if w.Suggestion != "" {
+				b.WriteString("  Suggestion: " + w.Suggestion + "\n")
+			}
+		}
+	}
+
+	b.WriteString("\n")
+	if len(r.Missing) == 0 {
+		b.WriteString("No missing test cases found.\n")
+	} else {
+		b.WriteString(fmt.Sprintf("Missing coverage: %d new test cases proposed (see the next message).\n", len(r.Missing)))
+	}
+
+	if len(r.Notes) > 0 {
+		b.WriteString("────────────────────\n")
+		b.WriteString("Notes:\n")
+		for _, n := range r.Notes {
+			b.WriteString("- " + n + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Review мок-аналізатора перевіряє набір лише лінтером.
+func (m *MockAnalyzer) Review(_ context.Context, existing []TestCase, _ string, _ []byte) (*CoverageReview, error) {
+	r := LintReview(existing)
+	r.Notes = append(r.Notes, "Missing test cases are proposed only with the AI analyzer (ANALYSIS_MODE=ollama).")
+	return r, nil
+}
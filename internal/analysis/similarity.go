@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// stopWords — службові слова, які не несуть сенсу для порівняння тест-кейсів.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "at": true, "is": true, "are": true, "be": true, "it": true,
+	"that": true, "this": true, "with": true, "for": true, "as": true, "by": true,
+	"user": true, "verify": true, "check": true, "should": true, "when": true,
+}
+
+// Tokenize нормалізує текст для порівняння: нижній регістр, лише літери/цифри,
+// без службових слів і з обрізаними типовими англійськими закінченнями.
+func Tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len(f) < 2 || stopWords[f] {
+			continue
+		}
+		out = append(out, stem(f))
+	}
+	return out
+}
+
+// stem — дуже проста евристика (buttons → button, clicking → click), без зовнішніх бібліотек.
+func stem(w string) string {
+	for _, suf := range []string{"ing", "ed", "es", "s"} {
+		if len(w) > len(suf)+2 && strings.HasSuffix(w, suf) {
+			return w[:len(w)-len(suf)]
+		}
+	}
+	return w
+}
+
+// TextSimilarity повертає косинусну подібність частот токенів двох текстів (0..1).
+func TextSimilarity(a, b string) float64 {
+	ta, tb := Tokenize(a), Tokenize(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	fa := make(map[string]float64, len(ta))
+	for _, t := range ta {
+		fa[t]++
+	}
+	fb := make(map[string]float64, len(tb))
+	for _, t := range tb {
+		fb[t]++
+	}
+	var dot, na, nb float64
+	for t, v := range fa {
+		dot += v * fb[t]
+		na += v * v
+	}
+	for _, v := range fb {
+		nb += v * v
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// CosineSimilarity порівнює два вектори ембедингів (0, якщо розмірності різні або вектор порожній).
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// TestCaseText — текст тест-кейсу, за яким шукаються схожі кейси.
+func TestCaseText(tc TestCase) string {
+	return strings.Join([]string{tc.Title, tc.Actual, tc.Expected}, "\n")
+}
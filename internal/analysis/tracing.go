@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"context"
+	"net/http"
+
+	"bugreportbot/internal/tracing"
+)
+
+// startOllamaSpan починає спан HTTP-виклику Ollama і передає трасу далі заголовком traceparent.
+func startOllamaSpan(ctx context.Context, req *http.Request, endpoint, model string) *tracing.Span {
+	spanCtx, span := tracing.StartClient(ctx, "ollama."+endpoint,
+		tracing.String("model", model),
+		tracing.String("url", req.URL.Redacted()))
+	if sc := tracing.SpanContextFromContext(spanCtx); sc.IsValid() {
+		req.Header.Set("traceparent", sc.TraceParent())
+	}
+	return span
+}
+
+// prepareImageTraced — prepareImageForOllama у спані image.prepare (декодування, зменшення, JPEG, хеш).
+func prepareImageTraced(ctx context.Context, raw []byte) ([]byte, uint64, error) {
+	_, span := tracing.Start(ctx, "image.prepare", tracing.Int("original_bytes", len(raw)))
+	defer span.End()
+	prepared, hash, err := prepareImageForOllama(raw)
+	span.RecordError(err)
+	span.SetAttributes(tracing.Int("prepared_bytes", len(prepared)))
+	return prepared, hash, err
+}
+
+// extractJSON прибирає обгортку ```json і витягує перший JSON-об'єкт з відповіді моделі (спан ollama.extract_json).
+func extractJSON(ctx context.Context, response string) string {
+	_, span := tracing.Start(ctx, "ollama.extract_json", tracing.Int("response_len", len(response)))
+	defer span.End()
+	jsonText := extractFirstJSONObject(stripMarkdownCodeBlock(response))
+	span.SetAttributes(tracing.Bool("found", jsonText != ""), tracing.Int("json_len", len(jsonText)))
+	return jsonText
+}
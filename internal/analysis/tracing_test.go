@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bugreportbot/internal/tracing"
+)
+
+func TestOllamaSpansUnderUpdate(t *testing.T) {
+	exp := tracing.NewInMemoryExporter()
+	tr := tracing.NewTracer(exp)
+	tracing.SetDefault(tr)
+	t.Cleanup(func() {
+		tracing.SetDefault(nil)
+		_ = tr.Shutdown(context.Background())
+	})
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"response":"` + "```json\\n{\\\"bugTitle\\\":\\\"Login fails\\\"}\\n```" + `"}`))
+	}))
+	defer srv.Close()
+
+	a := NewOllamaAnalyzer(srv.URL, "llava")
+	ctx, update := tracing.Start(context.Background(), "telegram.update")
+	response, err := a.generate(ctx, ollamaGenerateRequest{Model: "llava", Prompt: "describe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := extractJSON(ctx, response); got != `{"bugTitle":"Login fails"}` {
+		t.Errorf("extractJSON = %q", got)
+	}
+	update.End()
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := make(map[string]tracing.SpanData)
+	for _, s := range exp.Spans() {
+		spans[s.Name] = s
+	}
+	root, gen, parse := spans["telegram.update"], spans["ollama.generate"], spans["ollama.extract_json"]
+	if gen.Name == "" || parse.Name == "" {
+		t.Fatalf("missing spans: %+v", exp.Spans())
+	}
+	for _, s := range []tracing.SpanData{gen, parse} {
+		if s.ParentSpanID != root.SpanContext.SpanID || s.SpanContext.TraceID != root.SpanContext.TraceID {
+			t.Errorf("%s is not a child of telegram.update", s.Name)
+		}
+	}
+	if gen.Kind != tracing.KindClient || gen.Error {
+		t.Errorf("ollama.generate: kind %d, error %v", gen.Kind, gen.Error)
+	}
+	if want := gen.SpanContext.TraceParent(); traceparent != want {
+		t.Errorf("traceparent header = %q, want %q", traceparent, want)
+	}
+}
@@ -0,0 +1,95 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"bugreportbot/internal/analysis"
+)
+
+// Chromedp генерує Go-тести (пакет testing) з браузером через chromedp.
+type Chromedp struct{}
+
+func (Chromedp) Name() string        { return "chromedp" }
+func (Chromedp) Description() string { return "Go testing + chromedp" }
+
+func (Chromedp) FileName(res *analysis.BugAnalysis) string {
+	return slug(res.BugTitle, '_', 60) + "_test.go"
+}
+
+func (Chromedp) Generate(res *analysis.BugAnalysis) ([]byte, error) {
+	if res == nil || len(res.TestCases) == 0 {
+		return nil, errNoTestCases
+	}
+	var b strings.Builder
+	b.WriteString("package bugreport_test\n\n")
+	writeHeader(&b, res, "Steps are comments: replace them with chromedp actions and the placeholder assertions with real ones.")
+	b.WriteString(`import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func baseURL() string {
+	if u := os.Getenv("BASE_URL"); u != "" {
+		return u
+	}
+	return "http://localhost:3000"
+}
+
+func newBrowser(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := chromedp.NewContext(context.Background())
+	t.Cleanup(cancel)
+	ctx, cancelTimeout := context.WithTimeout(ctx, time.Minute)
+	t.Cleanup(cancelTimeout)
+	return ctx
+}
+`)
+	used := make(map[string]bool)
+	for i, tc := range res.TestCases {
+		base := goIdent(tc.ID)
+		if base == "" {
+			base = fmt.Sprintf("Case%d", i+1)
+		}
+		// go test бере лише TestXxx, де Xxx не починається з малої літери.
+		base = strings.ToUpper(base[:1]) + base[1:]
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = true
+
+		b.WriteString("\n// Test" + name + " — " + testName(tc) + "\n")
+		// У go test немає тегів: метадані бага — у документації тесту, щоб їх було видно поруч з кодом.
+		for _, f := range annotations(res) {
+			b.WriteString("// " + f.name + ": " + f.value + "\n")
+		}
+		if tags := bugTags(res); len(tags) > 0 {
+			b.WriteString("// Tags: " + strings.Join(tags, " ") + "\n")
+		}
+		b.WriteString("func Test" + name + "(t *testing.T) {\n")
+		b.WriteString("\tctx := newBrowser(t)\n")
+		writePreconditions(&b, "\t", tc)
+		b.WriteString("\terr := chromedp.Run(ctx,\n")
+		b.WriteString("\t\tchromedp.Navigate(baseURL()),\n")
+		for n, step := range tc.Steps {
+			writeStepComment(&b, "\t\t", n+1, step)
+		}
+		b.WriteString("\t)\n")
+		b.WriteString("\tif err != nil {\n\t\tt.Fatal(err)\n\t}\n\n")
+		b.WriteString("\t// Expected: " + expectedText(tc) + "\n")
+		b.WriteString("\tt.Fatal(" + strconv.Quote("TODO: assert — "+expectedText(tc)) + ")\n")
+		b.WriteString("}\n")
+	}
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("format generated go code: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,215 @@
+// Package codegen генерує заготовки автотестів з тест-кейсів: по одному тесту на TestCase,
+// кроки — коментарями, очікуваний результат — заглушкою перевірки, яку інженер замінює справжньою.
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"bugreportbot/internal/analysis"
+)
+
+// Generator будує файл автотестів для одного фреймворка.
+type Generator interface {
+	// Name — назва для /automate (playwright, cypress, chromedp).
+	Name() string
+	// Description — коротко про фреймворк для довідки.
+	Description() string
+	// FileName — ім'я файла для результату аналізу.
+	FileName(res *analysis.BugAnalysis) string
+	// Generate повертає вміст файла.
+	Generate(res *analysis.BugAnalysis) ([]byte, error)
+}
+
+// Generators — підтримувані фреймворки в порядку показу.
+var Generators = []Generator{Playwright{}, Cypress{}, Chromedp{}}
+
+// aliases — інші назви, під якими користувачі шукають фреймворки.
+var aliases = map[string]string{
+	"pw": "playwright", "playwright-ts": "playwright",
+	"cy": "cypress",
+	"go": "chromedp", "golang": "chromedp", "go-chromedp": "chromedp",
+}
+
+// ForName знаходить генератор за назвою або псевдонімом (без урахування регістру).
+func ForName(name string) (Generator, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if a, ok := aliases[name]; ok {
+		name = a
+	}
+	for _, g := range Generators {
+		if g.Name() == name {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// errNoTestCases повертається, якщо генерувати нема з чого.
+var errNoTestCases = fmt.Errorf("no test cases to generate tests from")
+
+// slug перетворює заголовок бага на ім'я файла: "Save button truncated" → "save-button-truncated".
+func slug(s string, sep rune, maxLen int) string {
+	var b strings.Builder
+	lastSep := true
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastSep = false
+		} else if !lastSep {
+			b.WriteRune(sep)
+			lastSep = true
+		}
+		if b.Len() >= maxLen {
+			break
+		}
+	}
+	out := strings.TrimRight(b.String(), string(sep))
+	if out == "" {
+		return "bug"
+	}
+	return out
+}
+
+// oneLine прибирає переноси рядків, щоб текст можна було вставити в коментар чи рядковий літерал.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// jsString повертає JS/TS-літерал в одинарних лапках.
+func jsString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(oneLine(s)) + "'"
+}
+
+// writeHeader пише шапку файла: з якого бага він згенерований, метадані бага (порожні поля пропускаються)
+// і підказку hint, що робити з заготовкою.
+func writeHeader(b *strings.Builder, res *analysis.BugAnalysis, hint string) {
+	b.WriteString("// Generated from the bug report: " + oneLine(res.BugTitle) + "\n")
+	for _, f := range bugMetadata(res) {
+		b.WriteString("// " + f.name + ": " + f.value + "\n")
+	}
+	b.WriteString("// " + hint + "\n\n")
+}
+
+// metaField — поле бага для шапки файла й анотацій тестів.
+type metaField struct {
+	name, value string
+}
+
+// bugMetadata — Summary, Component, Environment, Reproducibility і Tags бага, якщо вони відомі.
+func bugMetadata(res *analysis.BugAnalysis) []metaField {
+	var out []metaField
+	add := func(name, value string) {
+		if value = oneLine(value); value != "" {
+			out = append(out, metaField{name, value})
+		}
+	}
+	add("Summary", res.Summary)
+	add("Component", res.Component)
+	add("Environment", environmentText(res.Environment))
+	add("Reproducibility", res.Reproducibility)
+	add("Tags", strings.Join(bugTags(res), " "))
+	return out
+}
+
+// annotations — метадані бага без Summary і Tags: те, що фреймворки показують як анотації тестів.
+func annotations(res *analysis.BugAnalysis) []metaField {
+	var out []metaField
+	for _, f := range bugMetadata(res) {
+		if f.name != "Summary" && f.name != "Tags" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// environmentText — середовище одним рядком: "Android 14, Pixel 7, app 2.14.0".
+func environmentText(e *analysis.Environment) string {
+	if e.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	for _, p := range []string{e.OS, e.Device} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if v := strings.TrimSpace(e.AppVersion); v != "" {
+		parts = append(parts, "app "+v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// bugTags — мітки бага як теги тестів: "Login form" → "@login-form".
+func bugTags(res *analysis.BugAnalysis) []string {
+	var out []string
+	for _, t := range res.Tags {
+		if v := strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(t, "@", "")), "-")); v != "" {
+			out = append(out, "@"+v)
+		}
+	}
+	return out
+}
+
+// jsStringArray повертає JS/TS-масив рядкових літералів.
+func jsStringArray(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = jsString(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// testName — назва тесту: "TC-001: Verify ..." з тегом категорії для додаткових кейсів.
+func testName(tc analysis.TestCase) string {
+	name := tc.Title
+	if name == "" {
+		name = "Untitled test case"
+	}
+	if tc.ID != "" {
+		name = tc.ID + ": " + name
+	}
+	if tc.Category != "" && tc.Category != analysis.CategoryReproduction {
+		name += " @" + string(tc.Category)
+	}
+	return oneLine(name)
+}
+
+// goIdent перетворює ID тест-кейсу на частину імені Go-функції: "LOGIN-TC-001" → "LOGIN_TC_001".
+func goIdent(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// writeStepComment пише крок коментарем з відступом indent.
+func writeStepComment(b *strings.Builder, indent string, n int, step string) {
+	fmt.Fprintf(b, "%s// Step %d: %s\n", indent, n, oneLine(step))
+}
+
+// writePreconditions пише передумови кейсу коментарями з відступом indent.
+func writePreconditions(b *strings.Builder, indent string, tc analysis.TestCase) {
+	if len(tc.Preconditions) == 0 {
+		return
+	}
+	b.WriteString(indent + "// Preconditions:\n")
+	for _, p := range tc.Preconditions {
+		b.WriteString(indent + "// - " + oneLine(p) + "\n")
+	}
+}
+
+// expectedText — що має перевірити заглушка.
+func expectedText(tc analysis.TestCase) string {
+	if e := oneLine(tc.Expected); e != "" {
+		return e
+	}
+	return "expected result is not specified"
+}
@@ -0,0 +1,104 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"bugreportbot/internal/analysis"
+)
+
+func sampleAnalysis() *analysis.BugAnalysis {
+	return &analysis.BugAnalysis{
+		BugTitle:        "Login button does nothing",
+		Summary:         "Tapping Login on the sign-in screen\ndoes nothing, so users can't sign in.",
+		Component:       "Login form",
+		Environment:     &analysis.Environment{OS: "Android 14", Device: "Pixel 7", AppVersion: "2.14.0"},
+		Reproducibility: "Always",
+		Tags:            []string{"ui", "Login Form", "@auth"},
+		TestCases: []analysis.TestCase{{
+			ID:            "TC-001",
+			Title:         "Sign in with valid credentials",
+			Preconditions: []string{"User has an account"},
+			Steps:         []string{"Open the app", "Tap 'Login'"},
+			Expected:      "The dashboard opens",
+		}},
+	}
+}
+
+func TestGeneratorsIncludeBugMetadata(t *testing.T) {
+	header := []string{
+		"// Generated from the bug report: Login button does nothing",
+		"// Summary: Tapping Login on the sign-in screen does nothing, so users can't sign in.",
+		"// Component: Login form",
+		"// Environment: Android 14, Pixel 7, app 2.14.0",
+		"// Reproducibility: Always",
+		"// Tags: @ui @login-form @auth",
+	}
+	tests := []struct {
+		gen  Generator
+		want []string
+	}{
+		{Playwright{}, []string{
+			`test.describe('Login button does nothing', { tag: ['@ui', '@login-form', '@auth'], annotation: [` +
+				`{ type: 'component', description: 'Login form' }, ` +
+				`{ type: 'environment', description: 'Android 14, Pixel 7, app 2.14.0' }, ` +
+				`{ type: 'reproducibility', description: 'Always' }] }, () => {`,
+			"test('TC-001: Sign in with valid credentials', async ({ page }) => {",
+		}},
+		{Cypress{}, []string{
+			"describe('Login button does nothing', { tags: ['@ui', '@login-form', '@auth'] }, () => {",
+		}},
+		{Chromedp{}, []string{
+			"// TestTC_001 — TC-001: Sign in with valid credentials\n// Component: Login form\n// Environment: Android 14, Pixel 7, app 2.14.0\n// Reproducibility: Always\n// Tags: @ui @login-form @auth\nfunc TestTC_001(t *testing.T) {",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gen.Name(), func(t *testing.T) {
+			out, err := tt.gen.Generate(sampleAnalysis())
+			if err != nil {
+				t.Fatal(err)
+			}
+			src := string(out)
+			for _, want := range append(append([]string(nil), header...), tt.want...) {
+				if !strings.Contains(src, want) {
+					t.Errorf("generated file has no %q:\n%s", want, src)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratorsWithoutMetadata(t *testing.T) {
+	res := &analysis.BugAnalysis{
+		BugTitle:  "Typo",
+		TestCases: []analysis.TestCase{{Title: "Check the title", Steps: []string{"Open settings"}}},
+	}
+	tests := []struct {
+		gen  Generator
+		want string
+	}{
+		{Playwright{}, "test.describe('Typo', () => {"},
+		{Cypress{}, "describe('Typo', () => {"},
+		{Chromedp{}, "// TestCase1 — Check the title\nfunc TestCase1(t *testing.T) {"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.gen.Name(), func(t *testing.T) {
+			out, err := tt.gen.Generate(res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			src := string(out)
+			if !strings.Contains(src, tt.want) {
+				t.Errorf("generated file has no %q:\n%s", tt.want, src)
+			}
+			for _, field := range []string{"Summary:", "Component:", "Environment:", "Reproducibility:", "Tags:"} {
+				if strings.Contains(src, field) {
+					t.Errorf("empty %s written:\n%s", field, src)
+				}
+			}
+		})
+	}
+	if _, err := (Playwright{}).Generate(&analysis.BugAnalysis{BugTitle: "x"}); err == nil {
+		t.Error("want error without test cases")
+	}
+}
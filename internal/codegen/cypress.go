@@ -0,0 +1,48 @@
+package codegen
+
+import (
+	"strings"
+
+	"bugreportbot/internal/analysis"
+)
+
+// Cypress генерує spec-файл Cypress на TypeScript.
+type Cypress struct{}
+
+func (Cypress) Name() string        { return "cypress" }
+func (Cypress) Description() string { return "Cypress (TypeScript)" }
+
+func (Cypress) FileName(res *analysis.BugAnalysis) string {
+	return slug(res.BugTitle, '-', 60) + ".cy.ts"
+}
+
+func (Cypress) Generate(res *analysis.BugAnalysis) ([]byte, error) {
+	if res == nil || len(res.TestCases) == 0 {
+		return nil, errNoTestCases
+	}
+	var b strings.Builder
+	b.WriteString("/// <reference types=\"cypress\" />\n\n")
+	writeHeader(&b, res, "Steps are comments: replace them with cy commands and the placeholder assertions with real ones.")
+	// Теги в конфігурації describe розуміє плагін @cypress/grep: npx cypress run --env grepTags=@ui.
+	config := ""
+	if tags := bugTags(res); len(tags) > 0 {
+		config = ", { tags: " + jsStringArray(tags) + " }"
+	}
+	b.WriteString("describe(" + jsString(res.BugTitle) + config + ", () => {\n")
+	for i, tc := range res.TestCases {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("  it(" + jsString(testName(tc)) + ", () => {\n")
+		writePreconditions(&b, "    ", tc)
+		b.WriteString("    cy.visit('/');\n")
+		for n, step := range tc.Steps {
+			writeStepComment(&b, "    ", n+1, step)
+		}
+		b.WriteString("\n    // Expected: " + expectedText(tc) + "\n")
+		b.WriteString("    expect(false, " + jsString("TODO: assert — "+expectedText(tc)) + ").to.be.true;\n")
+		b.WriteString("  });\n")
+	}
+	b.WriteString("});\n")
+	return []byte(b.String()), nil
+}
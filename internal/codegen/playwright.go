@@ -0,0 +1,64 @@
+package codegen
+
+import (
+	"strings"
+
+	"bugreportbot/internal/analysis"
+)
+
+// Playwright генерує spec-файл @playwright/test на TypeScript.
+type Playwright struct{}
+
+func (Playwright) Name() string        { return "playwright" }
+func (Playwright) Description() string { return "Playwright Test (TypeScript)" }
+
+func (Playwright) FileName(res *analysis.BugAnalysis) string {
+	return slug(res.BugTitle, '-', 60) + ".spec.ts"
+}
+
+func (Playwright) Generate(res *analysis.BugAnalysis) ([]byte, error) {
+	if res == nil || len(res.TestCases) == 0 {
+		return nil, errNoTestCases
+	}
+	var b strings.Builder
+	b.WriteString("import { test, expect } from '@playwright/test';\n\n")
+	writeHeader(&b, res, "Steps are comments: replace them with page actions and the placeholder assertions with real ones.")
+	b.WriteString("const BASE_URL = process.env.BASE_URL ?? 'http://localhost:3000';\n\n")
+	b.WriteString("test.describe(" + jsString(res.BugTitle) + playwrightDetails(res) + ", () => {\n")
+	for i, tc := range res.TestCases {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("  test(" + jsString(testName(tc)) + ", async ({ page }) => {\n")
+		writePreconditions(&b, "    ", tc)
+		b.WriteString("    await page.goto(BASE_URL);\n")
+		for n, step := range tc.Steps {
+			writeStepComment(&b, "    ", n+1, step)
+		}
+		b.WriteString("\n    // Expected: " + expectedText(tc) + "\n")
+		b.WriteString("    expect(false, " + jsString("TODO: assert — "+expectedText(tc)) + ").toBeTruthy();\n")
+		b.WriteString("  });\n")
+	}
+	b.WriteString("});\n")
+	return []byte(b.String()), nil
+}
+
+// playwrightDetails — другий аргумент test.describe з тегами й анотаціями бага (Playwright 1.42+),
+// щоб запускати тести за тегом (--grep @ui) і бачити компонент і середовище у звіті; "" — додати нічого.
+func playwrightDetails(res *analysis.BugAnalysis) string {
+	var fields []string
+	if tags := bugTags(res); len(tags) > 0 {
+		fields = append(fields, "tag: "+jsStringArray(tags))
+	}
+	if anns := annotations(res); len(anns) > 0 {
+		items := make([]string, len(anns))
+		for i, a := range anns {
+			items[i] = "{ type: " + jsString(strings.ToLower(a.name)) + ", description: " + jsString(a.value) + " }"
+		}
+		fields = append(fields, "annotation: ["+strings.Join(items, ", ")+"]")
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return ", { " + strings.Join(fields, ", ") + " }"
+}
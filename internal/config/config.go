@@ -3,16 +3,109 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config зберігає базові налаштування бота.
 type Config struct {
-	BotToken string
+	BotToken     string
 	AnalysisMode string
 
 	// Ollama settings (used when AnalysisMode == "ollama")
 	OllamaURL   string
 	OllamaModel string
+
+	// DataDir — каталог для збережених даних бота (лічильники ID, налаштування чатів).
+	DataDir string
+	// TestCaseIDPrefix — базовий префікс ID тест-кейсів (TC → TC-001, LOGIN-TC-001).
+	TestCaseIDPrefix string
+
+	// OllamaEmbedModel — модель для ембедингів (/api/embeddings); порожня — пошук дублікатів лише за токенами.
+	OllamaEmbedModel string
+	// DuplicateSimilarity — поріг токенної подібності (0..1), з якого кейс вважається дублікатом.
+	DuplicateSimilarity float64
+	// DuplicateEmbedSimilarity — поріг косинусної подібності ембедингів (0..1).
+	DuplicateEmbedSimilarity float64
+
+	// BugSimilarity — поріг текстової подібності (0..1) для пошуку вже відомих багів.
+	BugSimilarity float64
+	// PHashMaxDistance — макс. відстань Геммінга між pHash скріншотів (0..64), щоб вважати їх одним багом.
+	PHashMaxDistance int
+
+	// AnalysisCacheTTL — скільки зберігати результат аналізу скріншота в кеші.
+	AnalysisCacheTTL time.Duration
+	// AnalysisCacheSize — макс. кількість результатів у кеші (0 — кеш вимкнено).
+	AnalysisCacheSize int
+
+	// OCREngine — розпізнавання тексту на скріншотах: "" / "none", "tesseract" або "http".
+	OCREngine string
+	// TesseractPath — шлях до tesseract CLI (для OCREngine == "tesseract").
+	TesseractPath string
+	// OCRLang — мови tesseract, наприклад "eng" або "eng+ukr".
+	OCRLang string
+	// OCRURL — адреса OCR-сервісу (для OCREngine == "http").
+	OCRURL string
+
+	// RedactByDefault — чи розмивати чутливі дані на скріншотах у чатах, які не змінювали це командою /redact.
+	RedactByDefault bool
+
+	// PIIScrub — маскувати персональні дані в текстах, відповідях і логах.
+	PIIScrub bool
+	// PIIRules — вбудовані правила через кому (email,phone,card,iban,token,ip); порожньо — усі.
+	PIIRules string
+	// PIICustomRules — власні правила "name=regex;name2=regex2".
+	PIICustomRules string
+
+	// PriorityRulesFile — JSON з власними значеннями Priority/Severity для проєктів.
+	PriorityRulesFile string
+
+	// LintMinScore — тест-кейси з оцінкою лінтера нижче цього значення модель переписує (0 — не перевіряти).
+	LintMinScore float64
+
+	// InputQualityGate — перевіряти текстові описи й перепитувати, якщо бракує деталей.
+	InputQualityGate bool
+
+	// GroupHashtag — хештег, яким у групах позначають звіт про баг без згадки бота ("" — лише згадки, команди й відповіді).
+	GroupHashtag string
+	// SessionTTL — скільки бот чекає на продовження незавершеного діалогу (уточнення, /report, імпортований набір).
+	SessionTTL time.Duration
+
+	// AdminUsers, AllowedUsers, AllowedChats — хто може користуватися ботом (ID користувачів і чатів Telegram).
+	// Якщо всі три списки порожні, контроль доступу вимкнено.
+	AdminUsers   []int64
+	AllowedUsers []int64
+	AllowedChats []int64
+	// InviteTTL — скільки діє код запрошення, створений /invite.
+	InviteTTL time.Duration
+
+	// ImageRateLimit, TextRateLimit — скільки аналізів скріншотів і текстів користувач може запустити за RateLimitWindow (0 — без обмежень).
+	ImageRateLimit  int
+	TextRateLimit   int
+	RateLimitWindow time.Duration
+	// ImageDailyQuota, TextDailyQuota — денні квоти користувача; ChatImageDailyQuota, ChatTextDailyQuota — спільні квоти групи (0 — без обмежень).
+	ImageDailyQuota     int
+	TextDailyQuota      int
+	ChatImageDailyQuota int
+	ChatTextDailyQuota  int
+
+	// LogLevel — мінімальний рівень логів (debug, info, warn, error); LogFormat — text або json.
+	LogLevel  string
+	LogFormat string
+
+	// MetricsAddr — адреса HTTP-сервера з /metrics, /healthz і /readyz ("" — сервер вимкнено).
+	MetricsAddr string
+
+	// MaxImageSizeMB — найбільший скріншот, який бот завантажує (Telegram Bot API віддає файли до 20 MB).
+	MaxImageSizeMB int
+
+	// OTLPEndpoint — адреса OTLP/HTTP колектора для трас ("" — трасування вимкнено); OTLPHeaders — "k=v,k2=v2".
+	OTLPEndpoint string
+	OTLPHeaders  string
+	// ServiceName — service.name у трасах.
+	ServiceName string
 }
 
 // Load читає конфігурацію зі змінних середовища.
@@ -39,11 +132,283 @@ func Load() (*Config, error) {
 		ollamaModel = "llava"
 	}
 
+	dataDir := os.Getenv("DATA_DIR")
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	idPrefix := os.Getenv("TESTCASE_ID_PREFIX")
+	if idPrefix == "" {
+		idPrefix = "TC"
+	}
+
+	dupSim, err := envFloat("DUPLICATE_SIMILARITY", 0.75)
+	if err != nil {
+		return nil, err
+	}
+	dupEmbedSim, err := envFloat("DUPLICATE_EMBED_SIMILARITY", 0.9)
+	if err != nil {
+		return nil, err
+	}
+	bugSim, err := envFloat("BUG_SIMILARITY", 0.6)
+	if err != nil {
+		return nil, err
+	}
+	phashDist, err := envInt("PHASH_MAX_DISTANCE", 10)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTL, err := envDuration("ANALYSIS_CACHE_TTL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cacheSize, err := envInt("ANALYSIS_CACHE_SIZE", 200)
+	if err != nil {
+		return nil, err
+	}
+	// Без OCR чутливі дані на скріншоті не знайти, тож за замовчуванням редагування вмикається лише разом з OCR_ENGINE.
+	ocrEngine := os.Getenv("OCR_ENGINE")
+	redact, err := envBool("REDACT_SCREENSHOTS", ocrEngine != "" && ocrEngine != "none")
+	if err != nil {
+		return nil, err
+	}
+	scrub, err := envBool("PII_SCRUB", true)
+	if err != nil {
+		return nil, err
+	}
+	gate, err := envBool("INPUT_QUALITY_GATE", true)
+	if err != nil {
+		return nil, err
+	}
+	lintMin, err := envFloat("LINT_MIN_SCORE", 0.7)
+	if err != nil {
+		return nil, err
+	}
+	hashtag, ok := os.LookupEnv("GROUP_HASHTAG")
+	if !ok {
+		hashtag = "#bug"
+	}
+	hashtag = strings.TrimSpace(hashtag)
+	if hashtag != "" && !strings.HasPrefix(hashtag, "#") {
+		hashtag = "#" + hashtag
+	}
+	sessionTTL, err := envDuration("SESSION_TTL", 30*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if sessionTTL <= 0 {
+		return nil, fmt.Errorf("invalid SESSION_TTL %s: must be positive", sessionTTL)
+	}
+	admins, err := envIDs("ADMIN_USERS")
+	if err != nil {
+		return nil, err
+	}
+	allowedUsers, err := envIDs("ALLOWED_USERS")
+	if err != nil {
+		return nil, err
+	}
+	allowedChats, err := envIDs("ALLOWED_CHATS")
+	if err != nil {
+		return nil, err
+	}
+	inviteTTL, err := envDuration("INVITE_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	imageRate, err := envInt("IMAGE_RATE_LIMIT", 10)
+	if err != nil {
+		return nil, err
+	}
+	textRate, err := envInt("TEXT_RATE_LIMIT", 30)
+	if err != nil {
+		return nil, err
+	}
+	rateWindow, err := envDuration("RATE_LIMIT_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	imageDaily, err := envInt("IMAGE_DAILY_QUOTA", 50)
+	if err != nil {
+		return nil, err
+	}
+	textDaily, err := envInt("TEXT_DAILY_QUOTA", 200)
+	if err != nil {
+		return nil, err
+	}
+	chatImageDaily, err := envInt("CHAT_IMAGE_DAILY_QUOTA", 0)
+	if err != nil {
+		return nil, err
+	}
+	chatTextDaily, err := envInt("CHAT_TEXT_DAILY_QUOTA", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxImageMB, err := envInt("MAX_IMAGE_SIZE_MB", 20)
+	if err != nil {
+		return nil, err
+	}
+	if maxImageMB <= 0 {
+		return nil, fmt.Errorf("invalid MAX_IMAGE_SIZE_MB %d: must be positive", maxImageMB)
+	}
+	logLevel := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL")))
+	switch logLevel {
+	case "":
+		logLevel = "info"
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q: use debug, info, warn or error", logLevel)
+	}
+	logFormat := strings.ToLower(strings.TrimSpace(os.Getenv("LOG_FORMAT")))
+	switch logFormat {
+	case "":
+		logFormat = "text"
+	case "text", "json":
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q: use text or json", logFormat)
+	}
+	metricsAddr, ok := os.LookupEnv("METRICS_ADDR")
+	if !ok {
+		// Лише локально: /metrics і /healthz не для інтернету; Docker відкриває порт явно (docker-compose.yml).
+		metricsAddr = "127.0.0.1:9090"
+	}
+	otlpEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if otlpEndpoint == "" {
+		otlpEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "bugreportbot"
+	}
+	priorityRules := os.Getenv("PRIORITY_RULES_FILE")
+	if priorityRules == "" {
+		priorityRules = filepath.Join(dataDir, "priority_rules.json")
+	}
+
 	return &Config{
-		BotToken:      token,
-		AnalysisMode:  mode,
-		OllamaURL:     ollamaURL,
-		OllamaModel:   ollamaModel,
+		BotToken:     token,
+		AnalysisMode: mode,
+		OllamaURL:    ollamaURL,
+		OllamaModel:  ollamaModel,
+
+		DataDir:          dataDir,
+		TestCaseIDPrefix: idPrefix,
+
+		OllamaEmbedModel:         os.Getenv("OLLAMA_EMBED_MODEL"),
+		DuplicateSimilarity:      dupSim,
+		DuplicateEmbedSimilarity: dupEmbedSim,
+
+		BugSimilarity:    bugSim,
+		PHashMaxDistance: phashDist,
+
+		AnalysisCacheTTL:  cacheTTL,
+		AnalysisCacheSize: cacheSize,
+
+		OCREngine:     ocrEngine,
+		TesseractPath: os.Getenv("TESSERACT_PATH"),
+		OCRLang:       os.Getenv("OCR_LANG"),
+		OCRURL:        os.Getenv("OCR_URL"),
+
+		RedactByDefault: redact,
+
+		PIIScrub:       scrub,
+		PIIRules:       os.Getenv("PII_RULES"),
+		PIICustomRules: os.Getenv("PII_CUSTOM_RULES"),
+
+		PriorityRulesFile: priorityRules,
+		LintMinScore:      lintMin,
+
+		InputQualityGate: gate,
+
+		GroupHashtag: hashtag,
+		SessionTTL:   sessionTTL,
+
+		AdminUsers:   admins,
+		AllowedUsers: allowedUsers,
+		AllowedChats: allowedChats,
+		InviteTTL:    inviteTTL,
+
+		ImageRateLimit:      imageRate,
+		TextRateLimit:       textRate,
+		RateLimitWindow:     rateWindow,
+		ImageDailyQuota:     imageDaily,
+		TextDailyQuota:      textDaily,
+		ChatImageDailyQuota: chatImageDaily,
+		ChatTextDailyQuota:  chatTextDaily,
+
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+
+		MetricsAddr: strings.TrimSpace(metricsAddr),
+
+		MaxImageSizeMB: maxImageMB,
+
+		OTLPEndpoint: strings.TrimSpace(otlpEndpoint),
+		OTLPHeaders:  os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"),
+		ServiceName:  serviceName,
 	}, nil
 }
 
+// envFloat читає дробове число зі змінної середовища (def, якщо змінна не задана).
+func envFloat(key string, def float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid number %q", key, v)
+	}
+	return f, nil
+}
+
+// envInt читає ціле число зі змінної середовища (def, якщо змінна не задана).
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid integer %q", key, v)
+	}
+	return n, nil
+}
+
+// envDuration читає тривалість у форматі Go (30s, 15m, 24h) зі змінної середовища.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid duration %q", key, v)
+	}
+	return d, nil
+}
+
+// envIDs читає список ID Telegram через кому або пробіл (ID груп від'ємні).
+func envIDs(key string) ([]int64, error) {
+	var ids []int64
+	for _, f := range strings.FieldsFunc(os.Getenv(key), func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid Telegram ID %q", key, f)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// envBool читає логічне значення (true/false, 1/0, on/off) зі змінної середовища.
+func envBool(key string, def bool) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(key))) {
+	case "":
+		return def, nil
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%s: invalid boolean %q", key, os.Getenv(key))
+	}
+}
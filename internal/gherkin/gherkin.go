@@ -0,0 +1,362 @@
+// Package gherkin перетворює тест-кейси на BDD-сценарії (.feature) і назад:
+// Preconditions — Given, Steps — When/And, Expected — Then, Feature — BugTitle.
+package gherkin
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"bugreportbot/internal/analysis"
+)
+
+// Render будує .feature-файл з результату аналізу. ID тест-кейсу йде на початку назви сценарію
+// ("Scenario: TC-001: ..."), щоб після редагування і повторного імпорту кейси зберегли свої ID.
+func Render(res *analysis.BugAnalysis) string {
+	var b strings.Builder
+	if res == nil {
+		return ""
+	}
+	if len(res.Tags) > 0 {
+		b.WriteString(renderTags(res.Tags) + "\n")
+	}
+	b.WriteString("Feature: " + oneLine(res.BugTitle) + "\n")
+	if res.Summary != "" {
+		b.WriteString("  " + oneLine(res.Summary) + "\n")
+	}
+	// Примітки не мають відповідника в Gherkin — лишаємо їх коментарями, Parse їх пропускає.
+	for _, n := range res.Notes {
+		if n = oneLine(n); n != "" {
+			b.WriteString("  # " + n + "\n")
+		}
+	}
+
+	for _, tc := range res.TestCases {
+		b.WriteString("\n")
+		if tags := scenarioTags(tc); len(tags) > 0 {
+			b.WriteString("  " + strings.Join(tags, " ") + "\n")
+		}
+		name := oneLine(tc.Title)
+		if tc.ID != "" {
+			name = tc.ID + ": " + name
+		}
+		b.WriteString("  Scenario: " + name + "\n")
+		writeSteps(&b, "Given", tc.Preconditions)
+		writeSteps(&b, "When", tc.Steps)
+		writeSteps(&b, "Then", splitLines(tc.Expected))
+		if a := oneLine(tc.Actual); a != "" {
+			b.WriteString("    # Actual: " + a + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeSteps(b *strings.Builder, keyword string, lines []string) {
+	for i, l := range lines {
+		l = oneLine(l)
+		if l == "" {
+			continue
+		}
+		kw := keyword
+		if i > 0 {
+			kw = "And"
+		}
+		b.WriteString("    " + kw + " " + l + "\n")
+	}
+}
+
+// scenarioTags — теги сценарію: пріоритет, критичність і категорія (@priority-high @severity-major @negative).
+func scenarioTags(tc analysis.TestCase) []string {
+	var tags []string
+	if tc.Priority != "" {
+		tags = append(tags, "@priority-"+tagValue(string(tc.Priority)))
+	}
+	if tc.Severity != "" {
+		tags = append(tags, "@severity-"+tagValue(string(tc.Severity)))
+	}
+	if tc.Category != "" && tc.Category != analysis.CategoryReproduction {
+		tags = append(tags, "@"+string(tc.Category))
+	}
+	return tags
+}
+
+func renderTags(tags []string) string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if v := tagValue(t); v != "" {
+			out = append(out, "@"+v)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// tagValue — значення, придатне для тегу: без пробілів і "@", у нижньому регістрі.
+func tagValue(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(s, "@", "")), "-"))
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func splitLines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// LooksLikeFeature повідомляє, що текст схожий на Gherkin (перший значущий рядок — тег або "Feature:").
+func LooksLikeFeature(text string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, "@") {
+			continue
+		}
+		return strings.HasPrefix(l, "Feature:")
+	}
+	return false
+}
+
+// scenarioIDRe виділяє ID тест-кейсу з назви сценарію: "LOGIN-TC-001: Verify ..." → LOGIN-TC-001.
+var scenarioIDRe = regexp.MustCompile(`^([A-Za-z0-9]+(?:-[A-Za-z0-9]+)*-\d+)\s*:\s*(.*)$`)
+
+// section — до якого поля TestCase належать кроки And/But.
+type section int
+
+const (
+	sectionNone section = iota
+	sectionGiven
+	sectionWhen
+	sectionThen
+)
+
+// Parse читає .feature-файл у BugAnalysis. Кроки Background додаються до передумов кожного сценарію;
+// таблиці та doc strings дописуються до попереднього кроку. Підтримуються лише англійські ключові слова.
+func Parse(text string) (*analysis.BugAnalysis, error) {
+	res := &analysis.BugAnalysis{}
+	var (
+		background   []string
+		inBackground bool
+		cur          *analysis.TestCase
+		sec          section
+		pendingTags  []string
+		featureSeen  bool
+		// inDescription — між Feature і першим Background/Scenario/Rule: кожен рядок там — опис фічі,
+		// навіть якщо починається з "When" чи "And" (як підсумок "When the user taps Login the app crashes").
+		inDescription bool
+		inDocString   bool
+		description   []string
+		lineNo        int
+	)
+
+	appendToLast := func(text string) {
+		if cur == nil {
+			return
+		}
+		var last *string
+		switch sec {
+		case sectionGiven:
+			if n := len(cur.Preconditions); n > 0 {
+				last = &cur.Preconditions[n-1]
+			}
+		case sectionWhen:
+			if n := len(cur.Steps); n > 0 {
+				last = &cur.Steps[n-1]
+			}
+		case sectionThen:
+			last = &cur.Expected
+		}
+		if last != nil {
+			*last = strings.TrimSpace(*last + " " + text)
+		}
+	}
+	addStep := func(sec section, text string) {
+		switch sec {
+		case sectionGiven:
+			cur.Preconditions = append(cur.Preconditions, text)
+		case sectionWhen:
+			cur.Steps = append(cur.Steps, text)
+		case sectionThen:
+			if cur.Expected != "" {
+				cur.Expected += "\n"
+			}
+			cur.Expected += text
+		}
+	}
+	finish := func() {
+		if cur != nil {
+			res.TestCases = append(res.TestCases, *cur)
+			cur = nil
+		}
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+
+		if strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "```") {
+			inDocString = !inDocString
+			continue
+		}
+		if inDocString {
+			appendToLast(line)
+			continue
+		}
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if cur != nil && strings.HasPrefix(line, "# Actual:") {
+				cur.Actual = strings.TrimSpace(strings.TrimPrefix(line, "# Actual:"))
+			}
+			continue
+		case strings.HasPrefix(line, "@"):
+			pendingTags = append(pendingTags, strings.Fields(line)...)
+			continue
+		case strings.HasPrefix(line, "|"):
+			appendToLast(line)
+			continue
+		}
+
+		keyword, rest := splitKeyword(line)
+		if inDescription {
+			switch keyword {
+			case "Background", "Scenario", "Scenario Outline", "Example", "Rule", "Feature":
+				inDescription = false
+			default:
+				description = append(description, line)
+				continue
+			}
+		}
+		switch keyword {
+		case "Feature":
+			if featureSeen {
+				return nil, fmt.Errorf("line %d: only one Feature per file is supported", lineNo)
+			}
+			featureSeen, inDescription = true, true
+			res.BugTitle = rest
+			res.Tags = tagsToList(pendingTags)
+			pendingTags = nil
+		case "Background":
+			finish()
+			inBackground, sec = true, sectionNone
+		case "Scenario", "Scenario Outline", "Example":
+			finish()
+			inBackground = false
+			cur = &analysis.TestCase{Preconditions: append([]string(nil), background...)}
+			if m := scenarioIDRe.FindStringSubmatch(rest); m != nil {
+				cur.ID, cur.Title = m[1], strings.TrimSpace(m[2])
+			} else {
+				cur.Title = rest
+			}
+			applyTags(cur, pendingTags)
+			pendingTags = nil
+			sec = sectionNone
+		case "Examples", "Rule":
+			// Приклади Scenario Outline і правила не мають відповідника в TestCase; таблицю прикладів пропускаємо.
+			sec = sectionNone
+		case "Given", "When", "Then", "And", "But", "*":
+			s := sec
+			switch keyword {
+			case "Given":
+				s = sectionGiven
+			case "When":
+				s = sectionWhen
+			case "Then":
+				s = sectionThen
+			}
+			if s == sectionNone {
+				return nil, fmt.Errorf("line %d: %q without a preceding Given/When/Then", lineNo, keyword)
+			}
+			sec = s
+			if inBackground {
+				if s != sectionGiven {
+					return nil, fmt.Errorf("line %d: Background may contain only Given steps", lineNo)
+				}
+				background = append(background, rest)
+				continue
+			}
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: step outside of a Scenario", lineNo)
+			}
+			addStep(s, rest)
+		default:
+			if !featureSeen {
+				return nil, fmt.Errorf("line %d: expected \"Feature:\", got %q", lineNo, line)
+			}
+			if cur == nil && !inBackground {
+				// Вільний текст під Rule — теж опис.
+				description = append(description, line)
+				continue
+			}
+			return nil, fmt.Errorf("line %d: unexpected %q (steps must start with Given, When, Then, And or But)", lineNo, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read feature: %w", err)
+	}
+	finish()
+
+	if !featureSeen {
+		return nil, fmt.Errorf("no \"Feature:\" found")
+	}
+	if len(res.TestCases) == 0 {
+		return nil, fmt.Errorf("no scenarios found")
+	}
+	res.Summary = strings.Join(description, " ")
+	return res, nil
+}
+
+// splitKeyword відділяє ключове слово Gherkin від решти рядка.
+func splitKeyword(line string) (string, string) {
+	for _, kw := range []string{"Feature", "Background", "Scenario Outline", "Scenario", "Example", "Examples", "Rule"} {
+		if strings.HasPrefix(line, kw+":") {
+			return kw, strings.TrimSpace(line[len(kw)+1:])
+		}
+	}
+	for _, kw := range []string{"Given", "When", "Then", "And", "But", "*"} {
+		if strings.HasPrefix(line, kw+" ") {
+			return kw, strings.TrimSpace(line[len(kw)+1:])
+		}
+	}
+	return "", line
+}
+
+// applyTags відновлює пріоритет, критичність і категорію з тегів сценарію.
+func applyTags(tc *analysis.TestCase, tags []string) {
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimPrefix(t, "@"))
+		switch {
+		case strings.HasPrefix(t, "priority-"):
+			if p, ok := analysis.ParsePriority(strings.TrimPrefix(t, "priority-")); ok {
+				tc.Priority = p
+			}
+		case strings.HasPrefix(t, "severity-"):
+			if s, ok := analysis.ParseSeverity(strings.TrimPrefix(t, "severity-")); ok {
+				tc.Severity = s
+			}
+		default:
+			if c := analysis.ParseCategory(t); c != analysis.CategoryReproduction {
+				tc.Category = c
+			}
+		}
+	}
+}
+
+func tagsToList(tags []string) []string {
+	var out []string
+	for _, t := range tags {
+		if t = strings.TrimPrefix(t, "@"); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
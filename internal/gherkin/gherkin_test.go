@@ -0,0 +1,171 @@
+package gherkin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bugreportbot/internal/analysis"
+)
+
+func TestRenderParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *analysis.BugAnalysis
+	}{
+		{
+			name: "summary starting with a step keyword",
+			res: &analysis.BugAnalysis{
+				BugTitle: "Login crashes the app",
+				Summary:  "When the user taps Login the app crashes",
+				Tags:     []string{"login", "android"},
+				TestCases: []analysis.TestCase{{
+					ID:            "TC-001",
+					Title:         "Sign in with valid credentials",
+					Preconditions: []string{"The app is installed", "The user has an account"},
+					Steps:         []string{"Open the app", "Enter valid credentials", "Tap 'Login'"},
+					Expected:      "The dashboard opens",
+					Actual:        "The app crashes",
+					Priority:      analysis.PriorityHigh,
+					Severity:      analysis.SeverityCritical,
+				}},
+			},
+		},
+		{
+			name: "summary with other keywords",
+			res: &analysis.BugAnalysis{
+				BugTitle: "Cart total",
+				Summary:  "Given a discount code, And then Then the total is wrong. Scenario outline of the bug: * see steps",
+				TestCases: []analysis.TestCase{
+					{
+						ID:       "SHOP-TC-7",
+						Title:    "Apply a discount code",
+						Steps:    []string{"Add an item to the cart", "Apply code SAVE10"},
+						Expected: "The total is reduced by 10%\nThe code is shown as applied",
+						Priority: analysis.PriorityMedium,
+						Severity: analysis.SeverityMajor,
+						Category: analysis.CategoryNegative,
+					},
+					{
+						ID:       "SHOP-TC-8",
+						Title:    "Remove the discount code",
+						Steps:    []string{"Remove the code"},
+						Expected: "The total returns to the original price",
+						Priority: analysis.PriorityLow,
+						Severity: analysis.SeverityMinor,
+						Category: analysis.CategoryRegression,
+					},
+				},
+			},
+		},
+		{
+			name: "no summary, no IDs",
+			res: &analysis.BugAnalysis{
+				BugTitle:  "Typo on the settings screen",
+				TestCases: []analysis.TestCase{{Title: "Check the settings title", Steps: []string{"Open settings"}, Expected: "The title reads Settings"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text := Render(tt.res)
+			if !LooksLikeFeature(text) {
+				t.Fatalf("rendered text does not look like a feature:\n%s", text)
+			}
+			got, err := Parse(text)
+			if err != nil {
+				t.Fatalf("Parse(Render(...)): %v\n%s", err, text)
+			}
+			if got.BugTitle != tt.res.BugTitle || got.Summary != tt.res.Summary {
+				t.Errorf("title/summary = %q / %q, want %q / %q", got.BugTitle, got.Summary, tt.res.BugTitle, tt.res.Summary)
+			}
+			if !reflect.DeepEqual(got.Tags, tt.res.Tags) {
+				t.Errorf("tags = %q, want %q", got.Tags, tt.res.Tags)
+			}
+			if !reflect.DeepEqual(got.TestCases, tt.res.TestCases) {
+				t.Errorf("test cases differ\n got: %+v\nwant: %+v\nfeature:\n%s", got.TestCases, tt.res.TestCases, text)
+			}
+		})
+	}
+}
+
+func TestParseBackgroundTablesAndDocStrings(t *testing.T) {
+	text := `# comment before the feature
+@checkout
+Feature: Checkout
+  As a buyer I want to pay.
+
+  Background:
+    Given the user is signed in
+
+  @priority-high
+  Scenario: PAY-TC-1: Pay by card
+    Given the cart is not empty
+    When the user pays with card
+      | number           | cvv |
+      | 4111111111111111 | 123 |
+    And confirms the payment
+    Then the order is placed
+    But no second charge is made
+    # Actual: the order is placed twice
+
+  Scenario Outline: Pay with <method>
+    When the user pays with <method>
+      """
+      extra details
+      """
+    Then it works
+
+    Examples:
+      | method |
+      | cash   |
+`
+	res, err := Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.BugTitle != "Checkout" || res.Summary != "As a buyer I want to pay." || !reflect.DeepEqual(res.Tags, []string{"checkout"}) {
+		t.Errorf("feature = %q / %q / %q", res.BugTitle, res.Summary, res.Tags)
+	}
+	if len(res.TestCases) != 2 {
+		t.Fatalf("got %d scenarios", len(res.TestCases))
+	}
+	card := res.TestCases[0]
+	want := analysis.TestCase{
+		ID:            "PAY-TC-1",
+		Title:         "Pay by card",
+		Preconditions: []string{"the user is signed in", "the cart is not empty"},
+		Steps:         []string{"the user pays with card | number           | cvv | | 4111111111111111 | 123 |", "confirms the payment"},
+		Expected:      "the order is placed\nno second charge is made",
+		Actual:        "the order is placed twice",
+		Priority:      analysis.PriorityHigh,
+	}
+	if !reflect.DeepEqual(card, want) {
+		t.Errorf("scenario 1\n got: %+v\nwant: %+v", card, want)
+	}
+	outline := res.TestCases[1]
+	if outline.Title != "Pay with <method>" || !reflect.DeepEqual(outline.Steps, []string{"the user pays with <method> extra details"}) {
+		t.Errorf("scenario 2 = %+v", outline)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"no feature", "Scenario: x\n  When y", `no "Feature:" found`},
+		{"two features", "Feature: a\nScenario: x\n When y\nFeature: b", "only one Feature"},
+		{"no scenarios", "Feature: a\n  some description", "no scenarios"},
+		{"and without section", "Feature: a\nScenario: x\n  And y", "without a preceding"},
+		{"when in background", "Feature: a\nBackground:\n  When y\nScenario: x\n  When z", "only Given"},
+		{"free text in scenario", "Feature: a\nScenario: x\n  When y\n  something else", "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package health — перевірки живості й готовності бота для /healthz і /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"bugreportbot/internal/metrics"
+)
+
+// dependencyUp — результат останньої перевірки кожної залежності (1 — доступна).
+var dependencyUp = metrics.NewGauge("bugreportbot_dependency_up", "Whether a dependency passed its last health check (1) or not (0).", "name")
+
+// CheckFunc перевіряє залежність; nil — усе гаразд.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	liveness bool
+
+	checkedAt time.Time
+	err       error
+}
+
+// Checker запускає перевірки і кешує їх результат на ttl, щоб часті запити моніторингу не навантажували залежності.
+type Checker struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	timeout time.Duration
+	checks  []*check
+}
+
+// NewChecker створює Checker; результат кожної перевірки кешується на ttl.
+func NewChecker(ttl time.Duration) *Checker {
+	return &Checker{ttl: ttl, timeout: 5 * time.Second}
+}
+
+// AddLiveness додає перевірку, без якої бот не працює взагалі: вона впливає і на /healthz, і на /readyz.
+func (c *Checker) AddLiveness(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &check{name: name, fn: fn, liveness: true})
+}
+
+// AddReadiness додає перевірку, яка впливає лише на /readyz (бот живий, але відповідає шаблонами).
+func (c *Checker) AddReadiness(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &check{name: name, fn: fn})
+}
+
+// Status — відповідь /healthz і /readyz.
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// run виконує перевірки (лише liveness, якщо onlyLiveness) і повертає статус та чи всі вони пройшли.
+func (c *Checker) run(ctx context.Context, onlyLiveness bool) (Status, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := Status{Status: "ok", Checks: make(map[string]string)}
+	ok := true
+	for _, ch := range c.checks {
+		if onlyLiveness && !ch.liveness {
+			continue
+		}
+		if time.Since(ch.checkedAt) >= c.ttl {
+			cctx, cancel := context.WithTimeout(ctx, c.timeout)
+			ch.err = ch.fn(cctx)
+			cancel()
+			ch.checkedAt = time.Now()
+			up := 1.0
+			if ch.err != nil {
+				up = 0
+			}
+			dependencyUp.Set(up, ch.name)
+		}
+		if ch.err != nil {
+			st.Checks[ch.name] = ch.err.Error()
+			ok = false
+		} else {
+			st.Checks[ch.name] = "ok"
+		}
+	}
+	if !ok {
+		st.Status = "unavailable"
+	}
+	return st, ok
+}
+
+// LivenessHandler — /healthz: лише перевірки, додані через AddLiveness.
+func (c *Checker) LivenessHandler() http.Handler {
+	return c.handler(true)
+}
+
+// ReadinessHandler — /readyz: усі перевірки.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return c.handler(false)
+}
+
+func (c *Checker) handler(onlyLiveness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st, ok := c.run(r.Context(), onlyLiveness)
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(st)
+	})
+}
+
+// Names повертає назви перевірок (для логу при старті).
+func (c *Checker) Names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.checks))
+	for _, ch := range c.checks {
+		names = append(names, ch.name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,242 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/storage"
+)
+
+// TestCaseRecord — тест-кейс, який бот уже видавав у межах проєкту.
+type TestCaseRecord struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Expected  string    `json:"expected,omitempty"`
+	Actual    string    `json:"actual,omitempty"`
+	ChatID    int64     `json:"chatId"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Embedding заповнюється, лише якщо налаштована embedding-модель.
+	Embedding []float64 `json:"embedding,omitempty"`
+}
+
+// Text повертає текст запису в тому ж форматі, що й analysis.TestCaseText.
+func (r TestCaseRecord) Text() string {
+	return analysis.TestCaseText(analysis.TestCase{Title: r.Title, Expected: r.Expected, Actual: r.Actual})
+}
+
+// BugRecord — баг, про який уже повідомляли в межах проєкту.
+type BugRecord struct {
+	BugTitle    string   `json:"bugTitle"`
+	Description string   `json:"description,omitempty"`
+	Component   string   `json:"component,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// PHash — перцептивний хеш скріншота (HasPHash=false для текстових звітів).
+	PHash       uint64   `json:"phash,string,omitempty"`
+	HasPHash    bool     `json:"hasPhash,omitempty"`
+	TestCaseIDs []string `json:"testCaseIds,omitempty"`
+	ChatID      int64    `json:"chatId"`
+	MessageID   int      `json:"messageId"`
+	// ReportedBy — автор звіту (@username або ім'я), щоб у групах було видно, хто повідомив про баг.
+	ReportedBy string    `json:"reportedBy,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Text — текст багу для порівняння.
+func (r BugRecord) Text() string {
+	return r.BugTitle + "\n" + r.Description
+}
+
+// Скільки записів кожного виду зберігається на проєкт: старіші відкидаються, щоб історія
+// (разом з ембедингами) не росла без меж і пошук схожих лишався швидким.
+const (
+	maxTestCasesPerProject = 2000
+	maxBugsPerProject      = 1000
+)
+
+type project struct {
+	TestCases []TestCaseRecord `json:"testCases"`
+	Bugs      []BugRecord      `json:"bugs,omitempty"`
+}
+
+// Store — історія звітів по проєктах, збережена в JSON-файлі.
+type Store struct {
+	mu       sync.Mutex
+	path     string
+	projects map[string]*project
+}
+
+// Open підвантажує історію з path (або створює порожню, якщо файла ще немає).
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:     path,
+		projects: make(map[string]*project),
+	}
+	if err := storage.LoadJSON(path, &s.projects); err != nil {
+		return nil, fmt.Errorf("load history: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Store) project(key string) *project {
+	p := s.projects[key]
+	if p == nil {
+		p = &project{}
+		s.projects[key] = p
+	}
+	return p
+}
+
+// save записує історію без відступів: ембединги з відступами роздувають файл у рази; s.mu має бути захоплений.
+func (s *Store) save() error {
+	return storage.SaveCompactJSON(s.path, s.projects)
+}
+
+// keepLatest лишає останні max записів (записи додаються в кінець, тож найстаріші — на початку).
+func keepLatest[T any](recs []T, max int) []T {
+	if len(recs) <= max {
+		return recs
+	}
+	return append([]T(nil), recs[len(recs)-max:]...)
+}
+
+// AddTestCases додає записи в історію проєкту; запис з тим самим ID (наприклад, після Edit) замінюється.
+func (s *Store) AddTestCases(projectKey string, recs ...TestCaseRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectKey)
+	for _, r := range recs {
+		replaced := false
+		for i := range p.TestCases {
+			if p.TestCases[i].ID == r.ID {
+				p.TestCases[i] = r
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			p.TestCases = append(p.TestCases, r)
+		}
+	}
+	p.TestCases = keepLatest(p.TestCases, maxTestCasesPerProject)
+	return s.save()
+}
+
+// TestCaseMatch — схожий тест-кейс з історії.
+type TestCaseMatch struct {
+	Record     TestCaseRecord
+	Similarity float64
+}
+
+// SimilarTestCases шукає в історії проєкту кейси, схожі на tc, з подібністю не нижче threshold
+// (за токенами) або embedThreshold (за ембедингами, якщо вони є в обох). Записи з тим самим ID пропускаються.
+func (s *Store) SimilarTestCases(projectKey string, tc analysis.TestCase, embedding []float64, threshold, embedThreshold float64) []TestCaseMatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectKey]
+	if p == nil {
+		return nil
+	}
+
+	text := analysis.TestCaseText(tc)
+	var out []TestCaseMatch
+	for _, r := range p.TestCases {
+		if r.ID == tc.ID {
+			continue
+		}
+		sim := analysis.TextSimilarity(text, r.Text())
+		ok := sim >= threshold
+		if es := analysis.CosineSimilarity(embedding, r.Embedding); es > 0 && es >= embedThreshold {
+			ok = true
+			if es > sim {
+				sim = es
+			}
+		}
+		if ok {
+			out = append(out, TestCaseMatch{Record: r, Similarity: sim})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Similarity > out[j].Similarity })
+	return out
+}
+
+// AddBug додає баг в історію проєкту.
+func (s *Store) AddBug(projectKey string, r BugRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.project(projectKey)
+	p.Bugs = keepLatest(append(p.Bugs, r), maxBugsPerProject)
+	return s.save()
+}
+
+// BugQuery — що відомо про новий звіт до генерації тест-кейсів.
+type BugQuery struct {
+	Text     string
+	PHash    uint64
+	HasPHash bool
+}
+
+// BugMatch — схожий баг з історії. Similarity — текстова подібність, Distance — відстань pHash (-1, якщо не порівнювалась).
+type BugMatch struct {
+	Record     BugRecord
+	Similarity float64
+	Distance   int
+}
+
+// SimilarBugs повертає до limit найсхожіших багів проєкту: за текстом (подібність ≥ threshold)
+// або за скріншотом (відстань pHash ≤ maxDistance).
+func (s *Store) SimilarBugs(projectKey string, q BugQuery, threshold float64, maxDistance, limit int) []BugMatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.projects[projectKey]
+	if p == nil {
+		return nil
+	}
+
+	var out []BugMatch
+	for _, r := range p.Bugs {
+		m := BugMatch{Record: r, Distance: -1}
+		if q.Text != "" {
+			m.Similarity = analysis.TextSimilarity(q.Text, r.Text())
+		}
+		if q.HasPHash && r.HasPHash {
+			m.Distance = analysis.HashDistance(q.PHash, r.PHash)
+		}
+		textMatch := m.Similarity >= threshold
+		imageMatch := m.Distance >= 0 && m.Distance <= maxDistance
+		if textMatch || imageMatch {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].score() > out[j].score() })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// score зводить текстову подібність і близькість скріншотів до одного числа для сортування.
+func (m BugMatch) score() float64 {
+	s := m.Similarity
+	if m.Distance >= 0 {
+		if img := 1 - float64(m.Distance)/64; img > s {
+			s = img
+		}
+	}
+	return s
+}
+
+// Ago форматує вік запису для повідомлень користувачу ("today", "yesterday", "3 days ago").
+func Ago(t, now time.Time) string {
+	days := int(now.Sub(t).Hours() / 24)
+	switch {
+	case days <= 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	default:
+		return fmt.Sprintf("%d days ago", days)
+	}
+}
@@ -0,0 +1,303 @@
+// Package metrics — мінімальний реєстр метрик у текстовому форматі Prometheus (без зовнішніх залежностей).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets — межі гістограм тривалості (секунди): від швидких текстових відповідей до хвилинних vision-аналізів.
+var DefBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// Registry зберігає метрики і пише їх у форматі експозиції Prometheus.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+// Default — реєстр, у який реєструються метрики з NewCounter, NewGauge і NewHistogram.
+var Default = NewRegistry()
+
+// NewRegistry створює порожній реєстр.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic("metrics: duplicate metric " + m.name())
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo пише всі метрики, відсортовані за назвою.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	ms := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	sort.Slice(ms, func(i, j int) bool { return ms[i].name() < ms[j].name() })
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range ms {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler віддає метрики реєстру для /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// Handler віддає метрики Default.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// series — набір значень метрики з однаковими назвами міток, ключ — значення міток.
+type series[T any] struct {
+	mu     sync.Mutex
+	labels []string
+	values map[string]*T
+	order  map[string][]string
+}
+
+func newSeries[T any](labels []string) series[T] {
+	return series[T]{labels: labels, values: make(map[string]*T), order: make(map[string][]string)}
+}
+
+// get повертає значення для міток (створює нове через init); s.mu має бути захоплений.
+func (s *series[T]) get(labelValues []string, init func() *T) *T {
+	if len(labelValues) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values, want %d", len(labelValues), len(s.labels)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	v, ok := s.values[key]
+	if !ok {
+		v = init()
+		s.values[key] = v
+		s.order[key] = append([]string(nil), labelValues...)
+	}
+	return v
+}
+
+// sortedKeys — ключі в стабільному порядку для виводу; s.mu має бути захоплений.
+func (s *series[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter — лічильник, що лише зростає.
+type Counter struct {
+	metricName, help string
+	series[float64]
+}
+
+// NewCounter реєструє лічильник у Default.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{metricName: name, help: help, series: newSeries[float64](labels)}
+	Default.register(c)
+	return c
+}
+
+// Inc збільшує лічильник з мітками labelValues на 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add збільшує лічильник на v (v < 0 ігнорується).
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.get(labelValues, newFloat) += v
+}
+
+func (c *Counter) name() string { return c.metricName }
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeHeader(w, c.metricName, c.help, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range c.sortedKeys() {
+		writeSample(w, c.metricName, c.labels, c.order[k], "", "", *c.values[k])
+	}
+}
+
+// Gauge — значення, що може і зростати, і зменшуватися.
+type Gauge struct {
+	metricName, help string
+	series[float64]
+}
+
+// NewGauge реєструє gauge у Default.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{metricName: name, help: help, series: newSeries[float64](labels)}
+	Default.register(g)
+	return g
+}
+
+// Set встановлює значення gauge з мітками labelValues.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.get(labelValues, newFloat) = v
+}
+
+// Add змінює значення gauge на v.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.get(labelValues, newFloat) += v
+}
+
+func (g *Gauge) name() string { return g.metricName }
+
+func (g *Gauge) write(w *bufio.Writer) {
+	writeHeader(w, g.metricName, g.help, "gauge")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, k := range g.sortedKeys() {
+		writeSample(w, g.metricName, g.labels, g.order[k], "", "", *g.values[k])
+	}
+}
+
+// Histogram рахує спостереження по кошиках (buckets), їх суму й кількість.
+type Histogram struct {
+	metricName, help string
+	buckets          []float64
+	series[histogramValue]
+}
+
+type histogramValue struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram реєструє гістограму в Default; buckets — верхні межі кошиків за зростанням (nil — DefBuckets).
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	h := &Histogram{metricName: name, help: help, buckets: buckets, series: newSeries[histogramValue](labels)}
+	Default.register(h)
+	return h
+}
+
+// Observe додає спостереження v для міток labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv := h.get(labelValues, func() *histogramValue {
+		return &histogramValue{counts: make([]uint64, len(h.buckets))}
+	})
+	for i, b := range h.buckets {
+		if v <= b {
+			hv.counts[i]++
+		}
+	}
+	hv.sum += v
+	hv.count++
+}
+
+func (h *Histogram) name() string { return h.metricName }
+
+func (h *Histogram) write(w *bufio.Writer) {
+	writeHeader(w, h.metricName, h.help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, k := range h.sortedKeys() {
+		hv := h.values[k]
+		values := h.order[k]
+		for i, b := range h.buckets {
+			writeSample(w, h.metricName+"_bucket", h.labels, values, "le", formatFloat(b), float64(hv.counts[i]))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labels, values, "le", "+Inf", float64(hv.count))
+		writeSample(w, h.metricName+"_sum", h.labels, values, "", "", hv.sum)
+		writeSample(w, h.metricName+"_count", h.labels, values, "", "", float64(hv.count))
+	}
+}
+
+func newFloat() *float64 { return new(float64) }
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample пише рядок "name{label="value",...} v"; extraName/extraValue — додаткова мітка (le у гістограм).
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, l, labelEscaper.Replace(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
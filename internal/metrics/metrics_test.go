@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHistogramExposition(t *testing.T) {
+	h := NewHistogram("test_request_seconds", "Request latency.", []float64{0.5, 1, 2}, "endpoint")
+	for _, v := range []float64{0.2, 0.7, 0.7, 1.5, 5} {
+		h.Observe(v, "generate")
+	}
+
+	var sb strings.Builder
+	if _, err := Default.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# HELP test_request_seconds Request latency.\n",
+		"# TYPE test_request_seconds histogram\n",
+		`test_request_seconds_bucket{endpoint="generate",le="0.5"} 1` + "\n",
+		`test_request_seconds_bucket{endpoint="generate",le="1"} 3` + "\n",
+		`test_request_seconds_bucket{endpoint="generate",le="2"} 4` + "\n",
+		`test_request_seconds_bucket{endpoint="generate",le="+Inf"} 5` + "\n",
+		`test_request_seconds_sum{endpoint="generate"} 8.1` + "\n",
+		`test_request_seconds_count{endpoint="generate"} 5` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition has no %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCounterAndGauge(t *testing.T) {
+	c := NewCounter("test_events_total", "Events.", "kind")
+	c.Inc("a")
+	c.Add(2, "a")
+	c.Add(-1, "a") // лічильник не зменшується
+	c.Inc(`b"q`)
+	g := NewGauge("test_queue_depth", "Queue depth.")
+	g.Set(3)
+	g.Add(-1)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+	for _, want := range []string{
+		"# TYPE test_events_total counter\n",
+		`test_events_total{kind="a"} 3` + "\n",
+		`test_events_total{kind="b\"q"} 1` + "\n",
+		"# TYPE test_queue_depth gauge\n",
+		"test_queue_depth 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition has no %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "test_events_total") > strings.Index(out, "test_queue_depth") {
+		t.Error("metrics are not sorted by name")
+	}
+}
+
+func TestDuplicateMetricPanics(t *testing.T) {
+	NewCounter("test_duplicate_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate metric did not panic")
+		}
+	}()
+	NewCounter("test_duplicate_total", "Second.")
+}
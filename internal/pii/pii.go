@@ -0,0 +1,216 @@
+package pii
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind — тип чутливих даних.
+type Kind string
+
+const (
+	Email Kind = "email"
+	Phone Kind = "phone"
+	Card  Kind = "card"
+	IBAN  Kind = "iban"
+	Token Kind = "token"
+	IP    Kind = "ip"
+)
+
+// Detector знаходить у тексті один тип чутливих даних. Validate (опційно) відсіює хибні збіги
+// регулярного виразу, наприклад номери карток, що не проходять перевірку Луна; Context (опційно) —
+// збіги, які видає оточення, наприклад номер версії "v 1.2.3.4", схожий на IP-адресу.
+type Detector struct {
+	Kind     Kind
+	Re       *regexp.Regexp
+	Validate func(string) bool
+	Context  func(text string, start, end int) bool
+}
+
+// Match — знайдений фрагмент: байтові позиції [Start, End) у тексті.
+type Match struct {
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// DefaultDetectors — детектори для скріншотів: email, телефони, номери карток, IBAN.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		{Kind: Email, Re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+		{Kind: Card, Re: regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`), Validate: luhnValid},
+		{Kind: IBAN, Re: regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`)},
+		{Kind: Phone, Re: phoneRe, Validate: phoneValid},
+	}
+}
+
+// TextDetectors — детектори для тексту: на додачу до DefaultDetectors ще токени/ключі та IP-адреси.
+// Токени йдуть першими, щоб довгий ключ не розпізнався частинами як телефон чи картка.
+func TextDetectors() []Detector {
+	return append([]Detector{
+		{Kind: Token, Re: regexp.MustCompile(`\d{8,10}:[A-Za-z0-9_\-]{35}\b`)},                          // Telegram bot token (у URL йде одразу після "bot")
+		{Kind: Token, Re: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)}, // JWT
+		{Kind: Token, Re: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{16,}=*`)},
+		{Kind: Token, Re: regexp.MustCompile(`(?i)\b(?:api[_-]?key|token|secret|password|passwd|pwd)\s*[:=]\s*\S{6,}`)},
+		{Kind: Token, Re: regexp.MustCompile(`\b[A-Za-z0-9_\-]{32,}\b`), Validate: opaqueToken},
+		{Kind: IP, Re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), Validate: ipv4Valid, Context: notVersionContext},
+		{Kind: IP, Re: regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b`)},
+	}, DefaultDetectors()...)
+}
+
+// Find повертає всі збіги детекторів у тексті, без перекриттів (раніший детектор у списку має пріоритет).
+func Find(text string, detectors []Detector) []Match {
+	var out []Match
+	for _, d := range detectors {
+		for _, loc := range d.Re.FindAllStringIndex(text, -1) {
+			if d.Validate != nil && !d.Validate(text[loc[0]:loc[1]]) {
+				continue
+			}
+			if d.Context != nil && !d.Context(text, loc[0], loc[1]) {
+				continue
+			}
+			m := Match{Kind: d.Kind, Start: loc[0], End: loc[1]}
+			if overlapsAny(out, m) {
+				continue
+			}
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
+
+func overlapsAny(ms []Match, m Match) bool {
+	for _, o := range ms {
+		if m.Start < o.End && o.Start < m.End {
+			return true
+		}
+	}
+	return false
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhnValid перевіряє контрольну суму номера картки.
+func luhnValid(s string) bool {
+	d := digitsOnly(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// phoneRe — телефон з "+" ("+380 67 123 4567") або з групами цифр ("(067) 123-45-67", "067 123 4567").
+// Просто ряд цифр ("Order #123456789") чи цифр через пробіл ("1 2 3 4 5") телефоном не вважається.
+var phoneRe = regexp.MustCompile(`\+\d[\d\s\-().]{7,}\d|(?:\(\d{2,4}\)\s?|\b\d{2,4}[\s\-.])\d{2,4}(?:[\s\-.]\d{2,4}){1,2}\b`)
+
+var (
+	isoDateRe = regexp.MustCompile(`\b\d{4}[-./](\d{1,2})[-./](\d{1,2})\b`)
+	dmyDateRe = regexp.MustCompile(`\b(\d{1,2})[-./](\d{1,2})[-./]\d{2,4}\b`)
+)
+
+// phoneValid відсіює дати, суми тощо: телефон має 9–15 цифр і не містить дати.
+func phoneValid(s string) bool {
+	n := len(digitsOnly(s))
+	return n >= 9 && n <= 15 && !containsDate(s)
+}
+
+// containsDate шукає дату "2024-03-15" або "15.03.2024" зі справжніми місяцем і днем,
+// щоб "123-45-67" у телефоні не вважалося датою.
+func containsDate(s string) bool {
+	for _, m := range isoDateRe.FindAllStringSubmatch(s, -1) {
+		if month, day := atoi(m[1]), atoi(m[2]); month >= 1 && month <= 12 && day >= 1 && day <= 31 {
+			return true
+		}
+	}
+	for _, m := range dmyDateRe.FindAllStringSubmatch(s, -1) {
+		// День і місяць можуть іти в будь-якому порядку (15.03 чи 03/15).
+		a, b := atoi(m[1]), atoi(m[2])
+		if a >= 1 && b >= 1 && a <= 31 && b <= 31 && (a <= 12 || b <= 12) {
+			return true
+		}
+	}
+	return false
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, r := range s {
+		n = n*10 + int(r-'0')
+	}
+	return n
+}
+
+// hexIDRe — шістнадцяткові дайджести й ідентифікатори: SHA-256, trace ID, UUID.
+var hexIDRe = regexp.MustCompile(`^[0-9a-fA-F]+(?:-[0-9a-fA-F]+)*$`)
+
+// opaqueToken — довгий рядок з літерами й цифрами, схожий на ключ. Шістнадцяткові дайджести та ID
+// (контрольні суми файлів, trace ID у логах) — не секрети, їх маскувати не можна.
+func opaqueToken(s string) bool {
+	return hasLetterAndDigit(s) && !hexIDRe.MatchString(s)
+}
+
+func hasLetterAndDigit(s string) bool {
+	letter, digit := false, false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+func ipv4Valid(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		n := 0
+		for _, r := range part {
+			n = n*10 + int(r-'0')
+		}
+		if n > 255 {
+			return false
+		}
+	}
+	return true
+}
+
+// versionPrefixRe — слово перед номером версії: "v 1.2.3.4", "version: 1.2.3.4", "build 10.0.19045.1".
+var versionPrefixRe = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(?:v|ver|version|build|release|версія|версия)\.?\s*[:=]?\s*$`)
+
+// notVersionContext відсіює номери версій, схожі на IPv4: після слова "version" тощо
+// або частину довшого номера "1.2.3.4.5".
+func notVersionContext(text string, start, end int) bool {
+	after := text[end:]
+	if len(after) >= 2 && after[0] == '.' && after[1] >= '0' && after[1] <= '9' {
+		return false
+	}
+	before := text[:start]
+	if n := len(before); n >= 2 && before[n-1] == '.' && before[n-2] >= '0' && before[n-2] <= '9' {
+		return false
+	}
+	return !versionPrefixRe.MatchString(before)
+}
@@ -0,0 +1,154 @@
+package pii
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Scrubber маскує чутливі дані в тексті: "mail me at a@b.com" → "mail me at [EMAIL]".
+type Scrubber struct {
+	detectors []Detector
+}
+
+// NewScrubber створює scrubber з вбудованими правилами kinds (nil — усі правила TextDetectors)
+// і власними правилами custom (назва → регулярний вираз), наприклад {"order": `ORD-\d{6}`}.
+func NewScrubber(kinds []Kind, custom map[string]string) (*Scrubber, error) {
+	enabled := make(map[Kind]bool, len(kinds))
+	for _, k := range kinds {
+		enabled[k] = true
+	}
+	var ds []Detector
+	for _, d := range TextDetectors() {
+		if kinds == nil || enabled[d.Kind] {
+			ds = append(ds, d)
+		}
+	}
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for _, name := range names {
+		re, err := regexp.Compile(custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("pii rule %q: %w", name, err)
+		}
+		// Власні правила мають пріоритет над вбудованими.
+		ds = append([]Detector{{Kind: Kind(name), Re: re}}, ds...)
+	}
+	return &Scrubber{detectors: ds}, nil
+}
+
+// Scrub замінює всі знайдені фрагменти на [KIND]. Nil-scrubber повертає текст без змін.
+func (s *Scrubber) Scrub(text string) string {
+	if s == nil || text == "" {
+		return text
+	}
+	matches := Find(text, s.detectors)
+	if len(matches) == 0 {
+		return text
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString("[" + strings.ToUpper(string(m.Kind)) + "]")
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// maxPendingLine — скільки байтів незавершеного рядка Writer тримає, перш ніж замаскувати й записати їх.
+const maxPendingLine = 64 << 10
+
+// Writer маскує чутливі дані в усьому, що в нього пишуть (вихід логера). Текст маскується цілими рядками:
+// якщо рядок прийшов кількома викликами Write, email чи токен на межі викликів теж буде знайдено.
+// Незавершений рядок чекає на "\n" (або Flush).
+type Writer struct {
+	w       io.Writer
+	s       *Scrubber
+	mu      sync.Mutex
+	pending []byte
+}
+
+func NewWriter(w io.Writer, s *Scrubber) *Writer {
+	return &Writer{w: w, s: s}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	n := bytes.LastIndexByte(w.pending, '\n') + 1
+	if n == 0 && len(w.pending) > maxPendingLine {
+		n = len(w.pending)
+	}
+	if n == 0 {
+		return len(p), nil
+	}
+	err := w.writeLocked(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush маскує й записує незавершений рядок.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeLocked(len(w.pending))
+}
+
+func (w *Writer) writeLocked(n int) error {
+	if n == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w.w, w.s.Scrub(string(w.pending[:n])))
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	return err
+}
+
+// ParseKinds розбирає список правил через кому ("email,phone,token"); "all" або порожній рядок — усі (nil).
+func ParseKinds(s string) ([]Kind, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "all") {
+		return nil, nil
+	}
+	known := map[Kind]bool{Email: true, Phone: true, Card: true, IBAN: true, Token: true, IP: true}
+	var out []Kind
+	for _, f := range strings.Split(s, ",") {
+		k := Kind(strings.ToLower(strings.TrimSpace(f)))
+		if k == "" {
+			continue
+		}
+		if !known[k] {
+			return nil, fmt.Errorf("unknown pii rule %q", k)
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
+
+// ParseCustomRules розбирає власні правила формату "name=regex;name2=regex2".
+func ParseCustomRules(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, expr, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" || expr == "" {
+			return nil, fmt.Errorf("invalid pii rule %q (want name=regex)", part)
+		}
+		out[strings.TrimSpace(name)] = expr
+	}
+	return out, nil
+}
@@ -0,0 +1,499 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/config"
+	"bugreportbot/internal/storage"
+)
+
+// Role — що користувачу дозволено робити з ботом.
+type Role string
+
+const (
+	// RoleViewer бачить допомогу і може експортувати вже згенеровані кейси (/automate), але не запускає аналіз.
+	RoleViewer Role = "viewer"
+	// RoleTester надсилає звіти, скріншоти і користується всіма командами, крім адміністративних.
+	RoleTester Role = "tester"
+	// RoleAdmin додатково керує користувачами та запрошеннями.
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleTester: 2, RoleAdmin: 3}
+
+func parseRole(v string) (Role, bool) {
+	r := Role(strings.ToLower(strings.TrimSpace(v)))
+	_, ok := roleRank[r]
+	return r, ok
+}
+
+// allows повідомляє, чи достатньо ролі r для дії, що потребує required ("" — доступно всім).
+func (r Role) allows(required Role) bool {
+	return required == "" || roleRank[r] >= roleRank[required]
+}
+
+// commandRoles — мінімальна роль для кожної команди бота; звіти (текст, скріншоти, файли) потребують RoleTester.
+var commandRoles = map[string]Role{
+	"start":      "",
+	"help":       "",
+	"join":       "",
+	"automate":   RoleViewer,
+	"describe":   RoleTester,
+	"text":       RoleTester,
+	"project":    RoleTester,
+	"reanalyze":  RoleTester,
+	"crop":       RoleTester,
+	"mode":       RoleTester,
+	"format":     RoleTester,
+	"redact":     RoleTester,
+	"report":     RoleTester,
+	"review":     RoleTester,
+	"cancel":     RoleTester,
+	"done":       RoleTester,
+	"skip":       RoleTester,
+	"users":      RoleAdmin,
+	"adduser":    RoleAdmin,
+	"removeuser": RoleAdmin,
+	"invite":     RoleAdmin,
+	"quota":      RoleAdmin,
+}
+
+// accessUser — користувач, доданий адміністратором або за кодом запрошення.
+type accessUser struct {
+	Role    Role      `json:"role"`
+	Name    string    `json:"name,omitempty"`
+	AddedBy int64     `json:"addedBy,omitempty"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// invite — код запрошення з роллю, яку отримає користувач.
+type invite struct {
+	Role      Role      `json:"role"`
+	CreatedBy int64     `json:"createdBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	UsesLeft  int       `json:"usesLeft"`
+}
+
+// accessState — те, що зберігається у файлі: додані користувачі та активні запрошення.
+type accessState struct {
+	Users   map[string]accessUser `json:"users"`
+	Invites map[string]invite     `json:"invites"`
+}
+
+// accessDenial — запис журналу відмов.
+type accessDenial struct {
+	Time     time.Time `json:"time"`
+	UserID   int64     `json:"userId"`
+	User     string    `json:"user,omitempty"`
+	ChatID   int64     `json:"chatId"`
+	Action   string    `json:"action"`
+	Required Role      `json:"required"`
+	Role     Role      `json:"role,omitempty"`
+}
+
+// denialReplyInterval — як часто відповідати одному користувачу про відмову, щоб бот не спамив.
+const denialReplyInterval = 10 * time.Minute
+
+// errAccessOff — зміни списку доступу без увімкненого контролю доступу: такий запис став би адміном,
+// щойно оператор задасть ADMIN_USERS чи ALLOWED_USERS, тож access.json у цьому режимі не змінюється.
+var errAccessOff = errors.New("access control is off")
+
+// accessControl вирішує, хто може користуватися ботом: адміністратори й дозволені ID з конфігу,
+// користувачі, додані командами або запрошеннями (зберігаються у файлі).
+type accessControl struct {
+	mu        sync.Mutex
+	path      string
+	auditPath string
+	enabled   bool
+	inviteTTL time.Duration
+
+	admins       map[int64]bool
+	allowedUsers map[int64]bool
+	allowedChats map[int64]bool
+
+	state      accessState
+	lastDenied map[int64]time.Time
+}
+
+func newAccessControl(cfg *config.Config, path, auditPath string) (*accessControl, error) {
+	a := &accessControl{
+		path:         path,
+		auditPath:    auditPath,
+		enabled:      len(cfg.AdminUsers)+len(cfg.AllowedUsers)+len(cfg.AllowedChats) > 0,
+		inviteTTL:    cfg.InviteTTL,
+		admins:       idSet(cfg.AdminUsers),
+		allowedUsers: idSet(cfg.AllowedUsers),
+		allowedChats: idSet(cfg.AllowedChats),
+		state:        accessState{Users: make(map[string]accessUser), Invites: make(map[string]invite)},
+		lastDenied:   make(map[int64]time.Time),
+	}
+	if err := storage.LoadJSON(path, &a.state); err != nil {
+		return nil, fmt.Errorf("load access list: %w", err)
+	}
+	if a.state.Users == nil {
+		a.state.Users = make(map[string]accessUser)
+	}
+	if a.state.Invites == nil {
+		a.state.Invites = make(map[string]invite)
+	}
+	return a, nil
+}
+
+func idSet(ids []int64) map[int64]bool {
+	m := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		m[id] = true
+	}
+	return m
+}
+
+// role повертає роль користувача в чаті ("" — доступу немає). Адміністратори з конфігу завжди admin;
+// роль, задана командою чи запрошенням, важливіша за списки ALLOWED_USERS / ALLOWED_CHATS.
+func (a *accessControl) role(userID, chatID int64) Role {
+	if !a.enabled {
+		return RoleTester
+	}
+	if a.admins[userID] {
+		return RoleAdmin
+	}
+	a.mu.Lock()
+	u, ok := a.state.Users[strconv.FormatInt(userID, 10)]
+	a.mu.Unlock()
+	if ok {
+		return u.Role
+	}
+	if a.allowedUsers[userID] || a.allowedChats[chatID] {
+		return RoleTester
+	}
+	return ""
+}
+
+// setUser додає користувача або змінює його роль.
+func (a *accessControl) setUser(userID int64, u accessUser) error {
+	if !a.enabled {
+		return errAccessOff
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.Users[strconv.FormatInt(userID, 10)] = u
+	return a.saveLocked()
+}
+
+// removeUser прибирає доданого користувача; false — його не було у файлі.
+func (a *accessControl) removeUser(userID int64) (bool, error) {
+	if !a.enabled {
+		return false, errAccessOff
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := strconv.FormatInt(userID, 10)
+	if _, ok := a.state.Users[key]; !ok {
+		return false, nil
+	}
+	delete(a.state.Users, key)
+	return true, a.saveLocked()
+}
+
+// createInvite створює код запрошення на uses використань.
+func (a *accessControl) createInvite(role Role, createdBy int64, uses int) (string, time.Time, error) {
+	if !a.enabled {
+		return "", time.Time{}, errAccessOff
+	}
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, fmt.Errorf("generate invite code: %w", err)
+	}
+	code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+	expires := time.Now().Add(a.inviteTTL)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.state.Invites[code] = invite{Role: role, CreatedBy: createdBy, ExpiresAt: expires, UsesLeft: uses}
+	return code, expires, a.saveLocked()
+}
+
+// redeem застосовує код запрошення. Роль користувача не знижується: якщо вона вже не нижча, код не витрачається.
+func (a *accessControl) redeem(code string, userID int64, name string) (Role, error) {
+	current := a.role(userID, 0)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	code = strings.ToUpper(strings.TrimSpace(code))
+	inv, ok := a.state.Invites[code]
+	if !ok || time.Now().After(inv.ExpiresAt) || inv.UsesLeft <= 0 {
+		return "", fmt.Errorf("invite code is invalid or expired")
+	}
+	if current != "" && current.allows(inv.Role) {
+		return current, nil
+	}
+	inv.UsesLeft--
+	if inv.UsesLeft == 0 {
+		delete(a.state.Invites, code)
+	} else {
+		a.state.Invites[code] = inv
+	}
+	a.state.Users[strconv.FormatInt(userID, 10)] = accessUser{Role: inv.Role, Name: name, AddedBy: inv.CreatedBy, AddedAt: time.Now()}
+	return inv.Role, a.saveLocked()
+}
+
+// saveLocked прибирає прострочені запрошення і зберігає стан; a.mu має бути захоплений.
+func (a *accessControl) saveLocked() error {
+	now := time.Now()
+	for code, inv := range a.state.Invites {
+		if now.After(inv.ExpiresAt) {
+			delete(a.state.Invites, code)
+		}
+	}
+	return storage.SaveJSON(a.path, a.state)
+}
+
+// logDenied пише відмову в лог і журнал; повертає true, якщо користувачу варто відповісти (не частіше за denialReplyInterval).
+func (a *accessControl) logDenied(logger *slog.Logger, d accessDenial) bool {
+	accessDeniedTotal.Inc()
+	logger.Warn("access denied", "user", d.User, "action", d.Action, "required", d.Required, "role", d.Role)
+	if err := storage.AppendJSONLine(a.auditPath, d); err != nil {
+		logger.Warn("access audit", "err", err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if last, ok := a.lastDenied[d.UserID]; ok && d.Time.Sub(last) < denialReplyInterval {
+		return false
+	}
+	a.lastDenied[d.UserID] = d.Time
+	return true
+}
+
+// authorize перевіряє, чи може автор повідомлення виконати його, і сам відповідає про відмову.
+func (b *Bot) authorize(msg *tgbotapi.Message) bool {
+	if !b.access.enabled {
+		// Без контролю доступу адміністраторів немає: адмін-команди (/adduser, /invite, /quota тощо) вимкнені.
+		if msg.IsCommand() && commandRoles[msg.Command()] == RoleAdmin {
+			_ = b.sendText(msg.Chat.ID, "Access control is off, so admin commands are disabled. Set ADMIN_USERS to enable them.")
+			return false
+		}
+		return true
+	}
+	if msg.From == nil {
+		return false
+	}
+	action, required := "message", RoleTester
+	if msg.IsCommand() {
+		// Невідома команда потребує тієї ж ролі, що й звичайне повідомлення; відповідь про неї дає handleUpdate.
+		action = "/" + msg.Command()
+		if r, ok := commandRoles[msg.Command()]; ok {
+			required = r
+		}
+	}
+	role := b.access.role(msg.From.ID, msg.Chat.ID)
+	if required == "" || role != "" && role.allows(required) {
+		return true
+	}
+
+	d := accessDenial{Time: time.Now(), UserID: msg.From.ID, User: authorName(msg.From), ChatID: msg.Chat.ID, Action: action, Required: required, Role: role}
+	if !b.access.logDenied(b.logger(msg.Chat.ID), d) {
+		return false
+	}
+	var text string
+	switch {
+	case role == "":
+		text = fmt.Sprintf("This bot is private. Ask an admin for an invite code and send /join <code>.\nYour Telegram ID: %d", msg.From.ID)
+	case required == RoleAdmin:
+		text = fmt.Sprintf("Only admins can use %s.", action)
+	default:
+		text = fmt.Sprintf("Your role (%s) can't do this. Ask an admin for %s access.", role, required)
+	}
+	_ = b.sendText(msg.Chat.ID, text)
+	return false
+}
+
+// handleJoin застосовує код запрошення (/join <code> або посилання t.me/<bot>?start=<code>).
+func (b *Bot) handleJoin(msg *tgbotapi.Message, code string) error {
+	chatID := msg.Chat.ID
+	if !b.access.enabled {
+		return b.sendText(chatID, "Access control is off — everyone can use this bot.")
+	}
+	if strings.TrimSpace(code) == "" {
+		return b.sendText(chatID, "Usage: /join <invite code>")
+	}
+	if msg.From == nil {
+		return nil
+	}
+	role, err := b.access.redeem(code, msg.From.ID, authorName(msg.From))
+	if err != nil {
+		b.access.logDenied(b.logger(chatID), accessDenial{Time: time.Now(), UserID: msg.From.ID, User: authorName(msg.From), ChatID: chatID, Action: "/join", Required: RoleViewer})
+		return b.sendText(chatID, "This invite code is invalid or has expired. Ask an admin for a new one.")
+	}
+	b.logger(chatID).Info("user joined with an invite", "user", authorName(msg.From), "role", role)
+	return b.sendText(chatID, fmt.Sprintf("Welcome! Your role: %s. Send /help to see what you can do.", role))
+}
+
+// targetUser визначає користувача для адмін-команди: ID в аргументах або автор повідомлення, на яке відповіли.
+// Повертає ID, ім'я та решту аргументів.
+func targetUser(msg *tgbotapi.Message) (int64, string, []string, error) {
+	args := strings.Fields(msg.CommandArguments())
+	if r := msg.ReplyToMessage; r != nil && r.From != nil && !r.From.IsBot {
+		return r.From.ID, authorName(r.From), args, nil
+	}
+	if len(args) == 0 {
+		return 0, "", nil, fmt.Errorf("no user")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("invalid user ID %q", args[0])
+	}
+	return id, "", args[1:], nil
+}
+
+// handleAddUser додає користувача або змінює його роль: /adduser <id> [role] або відповіддю на його повідомлення.
+func (b *Bot) handleAddUser(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	userID, name, rest, err := targetUser(msg)
+	if err != nil {
+		return b.sendText(chatID, "Usage: /adduser <user ID> [admin|tester|viewer], or reply to the user's message with /adduser [role].")
+	}
+	role := RoleTester
+	if len(rest) > 0 {
+		r, ok := parseRole(rest[0])
+		if !ok {
+			return b.sendText(chatID, fmt.Sprintf("Unknown role %q. Use admin, tester or viewer.", rest[0]))
+		}
+		role = r
+	}
+	if b.access.admins[userID] {
+		return b.sendText(chatID, fmt.Sprintf("%d is an admin from ADMIN_USERS; change the config to change their role.", userID))
+	}
+	if err := b.access.setUser(userID, accessUser{Role: role, Name: name, AddedBy: msg.From.ID, AddedAt: time.Now()}); err != nil {
+		return err
+	}
+	b.logger(chatID).Info("admin set user role", "target_user_id", userID, "target_user", name, "role", role)
+	return b.sendText(chatID, fmt.Sprintf("User %s now has the %s role.", userLabel(userID, name), role))
+}
+
+// handleRemoveUser прибирає користувача, доданого командою або запрошенням.
+func (b *Bot) handleRemoveUser(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	userID, name, _, err := targetUser(msg)
+	if err != nil {
+		return b.sendText(chatID, "Usage: /removeuser <user ID>, or reply to the user's message with /removeuser.")
+	}
+	if b.access.admins[userID] {
+		return b.sendText(chatID, fmt.Sprintf("%d is an admin from ADMIN_USERS; remove them from the config instead.", userID))
+	}
+	removed, err := b.access.removeUser(userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		if b.access.allowedUsers[userID] {
+			return b.sendText(chatID, fmt.Sprintf("%d is listed in ALLOWED_USERS; remove them from the config instead.", userID))
+		}
+		return b.sendText(chatID, fmt.Sprintf("User %d was not on the access list.", userID))
+	}
+	b.logger(chatID).Info("admin removed user", "target_user_id", userID)
+	text := fmt.Sprintf("User %s was removed.", userLabel(userID, name))
+	if len(b.access.allowedChats) > 0 {
+		text += " They can still use the bot in chats from ALLOWED_CHATS."
+	}
+	return b.sendText(chatID, text)
+}
+
+// handleUsers показує адміністраторів, користувачів, дозволені чати й активні запрошення.
+func (b *Bot) handleUsers(chatID int64) error {
+	a := b.access
+	var sb strings.Builder
+	sb.WriteString("Admins (ADMIN_USERS): " + joinIDs(a.admins) + "\n")
+	if len(a.allowedUsers) > 0 {
+		sb.WriteString("Testers (ALLOWED_USERS): " + joinIDs(a.allowedUsers) + "\n")
+	}
+	if len(a.allowedChats) > 0 {
+		sb.WriteString("Chats (ALLOWED_CHATS): " + joinIDs(a.allowedChats) + "\n")
+	}
+
+	a.mu.Lock()
+	keys := make([]string, 0, len(a.state.Users))
+	for k := range a.state.Users {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb.WriteString(fmt.Sprintf("\nAdded users (%d):\n", len(keys)))
+	for _, k := range keys {
+		u := a.state.Users[k]
+		id, _ := strconv.ParseInt(k, 10, 64)
+		sb.WriteString(fmt.Sprintf("• %s — %s (since %s)\n", userLabel(id, u.Name), u.Role, u.AddedAt.Format("2006-01-02")))
+	}
+	active := 0
+	now := time.Now()
+	for _, inv := range a.state.Invites {
+		if now.Before(inv.ExpiresAt) {
+			active++
+		}
+	}
+	a.mu.Unlock()
+	sb.WriteString(fmt.Sprintf("\nActive invite codes: %d", active))
+	return b.sendLongText(chatID, sb.String())
+}
+
+// handleInvite створює код запрошення: /invite [tester|viewer] [uses].
+func (b *Bot) handleInvite(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	args := strings.Fields(msg.CommandArguments())
+	role, uses := RoleTester, 1
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil && n > 0 {
+			uses = n
+			continue
+		}
+		r, ok := parseRole(arg)
+		if !ok || r == RoleAdmin {
+			return b.sendText(chatID, "Usage: /invite [tester|viewer] [number of uses]. Admins are added with ADMIN_USERS or /adduser <id> admin.")
+		}
+		role = r
+	}
+	code, expires, err := b.access.createInvite(role, msg.From.ID, uses)
+	if err != nil {
+		return err
+	}
+	b.logger(chatID).Info("admin created invite", "role", role, "uses", uses)
+	text := fmt.Sprintf("Invite code for the %s role (%d use(s), valid until %s):\n\n/join %s", role, uses, expires.Format("2006-01-02 15:04"), code)
+	if name := b.api.Self.UserName; name != "" {
+		text += fmt.Sprintf("\n\nor open https://t.me/%s?start=%s", name, code)
+	}
+	if isGroupChat(msg.Chat) {
+		text += "\n\nAnyone in this group can use this code; create invites in a private chat with the bot to share them privately."
+	}
+	return b.sendText(chatID, text)
+}
+
+func userLabel(id int64, name string) string {
+	if name == "" {
+		return strconv.FormatInt(id, 10)
+	}
+	return fmt.Sprintf("%d (%s)", id, name)
+}
+
+func joinIDs(set map[int64]bool) string {
+	if len(set) == 0 {
+		return "none"
+	}
+	ids := make([]int64, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/config"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role, required Role
+		want           bool
+	}{
+		{RoleViewer, "", true},
+		{"", "", true},
+		{"", RoleViewer, false},
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleTester, false},
+		{RoleTester, RoleViewer, true},
+		{RoleTester, RoleTester, true},
+		{RoleTester, RoleAdmin, false},
+		{RoleAdmin, RoleTester, true},
+		{RoleAdmin, RoleAdmin, true},
+	}
+	for _, tt := range tests {
+		if got := tt.role.allows(tt.required); got != tt.want {
+			t.Errorf("%q.allows(%q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+const (
+	adminID   = 1
+	testerID  = 2
+	viewerID  = 3
+	strangeID = 4
+	groupID   = -100
+)
+
+func newAccessTestBot(t *testing.T, cfg *config.Config) (*Bot, *fakeTelegram) {
+	t.Helper()
+	b, f := newTestBot(t)
+	dir := t.TempDir()
+	access, err := newAccessControl(cfg, filepath.Join(dir, "access.json"), filepath.Join(dir, "denied.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.access = access
+	return b, f
+}
+
+func commandMessage(userID, chatID int64, text string) *tgbotapi.Message {
+	msg := &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: chatID, Type: "private"}, Text: text}
+	if chatID < 0 {
+		msg.Chat.Type = "supergroup"
+	}
+	if strings.HasPrefix(text, "/") {
+		end := len(text)
+		if i := strings.IndexByte(text, ' '); i > 0 {
+			end = i
+		}
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: end}}
+	}
+	return msg
+}
+
+func TestAuthorize(t *testing.T) {
+	b, f := newAccessTestBot(t, &config.Config{AdminUsers: []int64{adminID}, AllowedChats: []int64{groupID}})
+	if err := b.access.setUser(testerID, accessUser{Role: RoleTester}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.access.setUser(viewerID, accessUser{Role: RoleViewer}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		user      int64
+		chat      int64
+		text      string
+		want      bool
+		wantReply string // початок відповіді про відмову ("" — бот мовчить)
+	}{
+		{"help for anyone", strangeID, strangeID, "/help", true, ""},
+		{"stranger's report", strangeID, strangeID, "login is broken", false, "This bot is private"},
+		{"tester's report", testerID, testerID, "login is broken", true, ""},
+		{"viewer's report", viewerID, viewerID, "login is broken", false, "Your role (viewer) can't do this"},
+		{"viewer exports", viewerID, viewerID, "/automate playwright", true, ""},
+		{"tester's admin command", testerID, testerID, "/adduser 5", false, "Only admins can use /adduser"},
+		{"admin command", adminID, adminID, "/users", true, ""},
+		{"unknown command from a tester", testerID, testerID, "/hello", true, ""},
+		{"unknown command from a stranger", strangeID + 10, strangeID + 10, "/hello", false, "This bot is private"},
+		{"allowed group", strangeID + 20, groupID, "login is broken", true, ""},
+		{"no sender", 0, strangeID, "hi", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := commandMessage(tt.user, tt.chat, tt.text)
+			if tt.user == 0 {
+				msg.From = nil
+			}
+			before := len(f.texts())
+			if got := b.authorize(msg); got != tt.want {
+				t.Fatalf("authorize(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+			replies := f.texts()[before:]
+			switch {
+			case tt.wantReply == "" && len(replies) > 0:
+				t.Errorf("unexpected reply %q", replies)
+			case tt.wantReply != "" && (len(replies) != 1 || !strings.HasPrefix(replies[0], tt.wantReply)):
+				t.Errorf("replies = %q, want one starting with %q", replies, tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAccessOff(t *testing.T) {
+	b, f := newAccessTestBot(t, &config.Config{})
+	for _, text := range []string{"login is broken", "/report", "/hello"} {
+		if !b.authorize(commandMessage(strangeID, strangeID, text)) {
+			t.Errorf("authorize(%q) = false with access control off", text)
+		}
+	}
+	for _, text := range []string{"/adduser 5 admin", "/quota", "/invite"} {
+		if b.authorize(commandMessage(strangeID, strangeID, text)) {
+			t.Errorf("admin command %q allowed with access control off", text)
+		}
+	}
+	if got := f.texts(); len(got) != 3 || !strings.HasPrefix(got[0], "Access control is off") {
+		t.Errorf("replies = %q", got)
+	}
+	if err := b.access.setUser(strangeID, accessUser{Role: RoleAdmin}); err != errAccessOff {
+		t.Errorf("setUser with access control off: err = %v, want errAccessOff", err)
+	}
+}
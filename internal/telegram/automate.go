@@ -0,0 +1,41 @@
+package telegram
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/codegen"
+)
+
+// handleAutomate надсилає заготовку автотестів для останнього результату (або того, на чиє "Edit" відповіли).
+func (b *Bot) handleAutomate(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	name := msg.CommandArguments()
+	gen, ok := codegen.ForName(name)
+	if !ok {
+		var sb strings.Builder
+		if strings.TrimSpace(name) != "" {
+			sb.WriteString(fmt.Sprintf("Unknown framework %q.\n\n", strings.TrimSpace(name)))
+		}
+		sb.WriteString("Usage: /automate <framework>\n")
+		for _, g := range codegen.Generators {
+			sb.WriteString(fmt.Sprintf("• %s — %s\n", g.Name(), g.Description()))
+		}
+		sb.WriteString("\nReply to an \"Edit\" message to use those test cases instead of the latest ones.")
+		return b.sendText(chatID, sb.String())
+	}
+
+	res := b.targetResult(msg)
+	if res == nil {
+		return b.sendText(chatID, "No test cases yet — send a screenshot or a bug description first.")
+	}
+	src, err := gen.Generate(res)
+	if err != nil {
+		b.logger(chatID).Warn("generate tests", "framework", gen.Name(), "err", err)
+		return b.sendText(chatID, "Could not generate tests: "+err.Error())
+	}
+	caption := fmt.Sprintf("%s skeleton: %d tests. Steps are comments; replace the TODO assertions with real checks.", gen.Description(), len(res.TestCases))
+	return b.sendDocument(chatID, gen.FileName(res), src, caption)
+}
@@ -2,80 +2,291 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
 	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/config"
+	"bugreportbot/internal/gherkin"
+	"bugreportbot/internal/history"
+	"bugreportbot/internal/logging"
+	"bugreportbot/internal/pii"
+	"bugreportbot/internal/tracing"
 )
 
 // editPromptText is sent after each result; when the user replies to it, we regenerate test cases from the reply.
 const editPromptText = "✏️ Edit: reply to this message with your corrections or extra details, and I'll regenerate test cases."
 
+// maxStoredResults обмежує кількість результатів, які бот пам'ятає для редагування.
+const maxStoredResults = 1000
+
 // Bot інкапсулює логіку обробки апдейтів Telegram.
 type Bot struct {
 	api      *tgbotapi.BotAPI
 	analyzer analysis.Analyzer
+
+	ids      *analysis.IDAllocator
+	idPrefix string
+	settings *settingsStore
+
+	history           *history.Store
+	embedder          analysis.Embedder
+	dupThreshold      float64
+	dupEmbedThreshold float64
+	bugThreshold      float64
+	phashMaxDistance  int
+
+	redactor           *analysis.Redactor
+	redactByDefault    bool
+	redactionAuditPath string
+	scrubber           *pii.Scrubber
+
+	// drafts, interviews, suites — незавершені діалоги по сесіях; покинуті зникають через SessionTTL.
+	qualityGate bool
+	drafts      *sessionStore[descriptionDraft]
+	interviews  *sessionStore[bugInterview]
+	suites      *sessionStore[testSuite]
+
+	// origins — тема й автор поточного повідомлення кожного чату; mentionRe/hashtagRe — як до бота звертаються в групах.
+	origins   origins
+	mentionRe *regexp.Regexp
+	hashtagRe *regexp.Regexp
+
+	// limiter — ліміти й денні квоти аналізів (IMAGE_RATE_LIMIT, TEXT_DAILY_QUOTA тощо).
+	limiter *rateLimiter
+
+	// downloads завантажує файли з Telegram; maxImageBytes — найбільший скріншот, який бот приймає (MAX_IMAGE_SIZE_MB).
+	downloads     *downloader
+	maxImageBytes int64
+
+	// lastPoll — час (UnixNano) останнього успішного getUpdates, для перевірки зв'язку з Telegram.
+	lastPoll atomic.Int64
+
+	// access — хто і з якою роллю може користуватися ботом (ADMIN_USERS, ALLOWED_USERS, ALLOWED_CHATS, /invite).
+	access *accessControl
+
+	// results зберігає надіслані результати за ID повідомлення "Edit", щоб після редагування
+	// кейси зберігали свої ID.
+	resultsMu sync.Mutex
+	results   map[messageKey]*analysis.BugAnalysis
+	// latest — останній результат кожного чату (для команд на кшталт /automate без reply).
+	latest map[int64]*analysis.BugAnalysis
 }
 
-// NewBot створює новий екземпляр Bot.
-func NewBot(api *tgbotapi.BotAPI, analyzer analysis.Analyzer) *Bot {
+// messageKey ідентифікує повідомлення (ID повідомлень унікальні лише в межах чату).
+type messageKey struct {
+	chatID    int64
+	messageID int
+}
+
+// NewBot створює новий екземпляр Bot і підвантажує збережений стан з cfg.DataDir.
+func NewBot(api *tgbotapi.BotAPI, analyzer analysis.Analyzer, cfg *config.Config) (*Bot, error) {
+	ids, err := analysis.NewIDAllocator(filepath.Join(cfg.DataDir, "testcase_ids.json"))
+	if err != nil {
+		return nil, err
+	}
+	settings, err := newSettingsStore(filepath.Join(cfg.DataDir, "chats.json"))
+	if err != nil {
+		return nil, err
+	}
+	hist, err := history.Open(filepath.Join(cfg.DataDir, "history.json"))
+	if err != nil {
+		return nil, err
+	}
+	access, err := newAccessControl(cfg, filepath.Join(cfg.DataDir, "access.json"), filepath.Join(cfg.DataDir, "access_denied.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := newRateLimiter(cfg, filepath.Join(cfg.DataDir, "quotas.json"))
+	if err != nil {
+		return nil, err
+	}
+	mentionRe, hashtagRe := addressPatterns(api.Self.UserName, cfg.GroupHashtag)
 	return &Bot{
 		api:      api,
 		analyzer: analyzer,
-	}
+		ids:      ids,
+		idPrefix: cfg.TestCaseIDPrefix,
+		settings: settings,
+
+		history:           hist,
+		dupThreshold:      cfg.DuplicateSimilarity,
+		dupEmbedThreshold: cfg.DuplicateEmbedSimilarity,
+		bugThreshold:      cfg.BugSimilarity,
+		phashMaxDistance:  cfg.PHashMaxDistance,
+
+		redactByDefault:    cfg.RedactByDefault,
+		redactionAuditPath: filepath.Join(cfg.DataDir, "redaction_audit.jsonl"),
+
+		qualityGate: cfg.InputQualityGate,
+		drafts:      newSessionStore[descriptionDraft](cfg.SessionTTL),
+		interviews:  newSessionStore[bugInterview](cfg.SessionTTL),
+		suites:      newSessionStore[testSuite](cfg.SessionTTL),
+
+		origins:   origins{byChat: make(map[int64]origin)},
+		mentionRe: mentionRe,
+		hashtagRe: hashtagRe,
+		access:    access,
+		limiter:   limiter,
+
+		downloads:     newDownloader(),
+		maxImageBytes: int64(cfg.MaxImageSizeMB) << 20,
+
+		results: make(map[messageKey]*analysis.BugAnalysis),
+		latest:  make(map[int64]*analysis.BugAnalysis),
+	}, nil
 }
 
 // Run запускає цикл обробки апдейтів до завершення контексту.
 func (b *Bot) Run(ctx context.Context) error {
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-
-	updates := b.api.GetUpdatesChan(u)
+	updates := b.pollUpdates(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case upd, ok := <-updates:
+		case in, ok := <-updates:
 			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 				return fmt.Errorf("updates channel closed")
 			}
-			if err := b.handleUpdate(ctx, &upd); err != nil {
-				log.Printf("[DEBUG] handleUpdate error: %v", err)
+			updateQueueDepth.Set(float64(len(updates)))
+			upd := in.update
+			// Кожен апдейт отримує свій correlation ID: ним позначені всі логи його обробки, включно з викликами Ollama.
+			correlationID := logging.NewCorrelationID()
+			updCtx := logging.WithCorrelationID(ctx, correlationID)
+			// Спан апдейту — корінь траси: під ним завантаження файлу, підготовка зображення, виклики Ollama і відповіді.
+			updCtx, span := tracing.Start(updCtx, "telegram.update",
+				tracing.Int("update_id", upd.UpdateID),
+				tracing.String("correlation_id", correlationID))
+			if upd.Message != nil {
+				span.SetAttributes(
+					tracing.Int64("chat_id", upd.Message.Chat.ID),
+					tracing.String("type", updateType(upd.Message)))
+			}
+			err := b.handleUpdate(updCtx, &upd, in.threadID)
+			span.RecordError(err)
+			span.End()
+			if err != nil {
+				updateErrorsTotal.Inc()
+				b.logger(upd.FromChat().ID).Error("handle update", "err", err)
 				_ = b.sendText(upd.FromChat().ID, "Внутрішня помилка. Спробуйте ще раз. (Деталі — у консолі, де запущено бота.)")
 			}
 		}
 	}
 }
 
-func (b *Bot) handleUpdate(ctx context.Context, upd *tgbotapi.Update) error {
+// handleUpdate обробляє одне повідомлення; threadID — тема форуму, куди треба відповідати (0 — без теми).
+func (b *Bot) handleUpdate(ctx context.Context, upd *tgbotapi.Update, threadID int) error {
 	if upd.Message == nil {
 		return nil
 	}
 
 	chatID := upd.Message.Chat.ID
+	b.origins.set(chatID, newOrigin(ctx, upd.Message, threadID))
+	updatesTotal.Inc(updateType(upd.Message))
+	b.logger(chatID).Debug("update received", "update_id", upd.UpdateID, "type", updateType(upd.Message), "thread_id", threadID)
+
+	// У групах бот відповідає лише тоді, коли до нього звертаються, і не бачить у звіті згадку чи хештег.
+	if isGroupChat(upd.Message.Chat) {
+		if !b.addressedToBot(upd.Message) {
+			return nil
+		}
+		if !upd.Message.IsCommand() {
+			upd.Message.Text = b.stripAddress(upd.Message.Text)
+			upd.Message.Caption = b.stripAddress(upd.Message.Caption)
+		}
+	}
+
+	if !b.authorize(upd.Message) {
+		return nil
+	}
 
 	if upd.Message.IsCommand() {
 		switch upd.Message.Command() {
 		case "start":
+			// Посилання-запрошення t.me/<bot>?start=<code> приходить як "/start <code>".
+			if code := upd.Message.CommandArguments(); code != "" {
+				return b.handleJoin(upd.Message, code)
+			}
 			return b.handleStart(chatID)
+		case "join":
+			return b.handleJoin(upd.Message, upd.Message.CommandArguments())
+		case "users":
+			return b.handleUsers(chatID)
+		case "adduser":
+			return b.handleAddUser(upd.Message)
+		case "removeuser":
+			return b.handleRemoveUser(upd.Message)
+		case "invite":
+			return b.handleInvite(upd.Message)
+		case "quota":
+			return b.handleQuota(upd.Message)
 		case "describe", "text":
 			return b.handleDescribeHint(chatID)
 		case "help":
 			return b.handleHelp(chatID)
+		case "project":
+			return b.handleProject(chatID, upd.Message.CommandArguments())
+		case "reanalyze":
+			return b.handleReanalyze(ctx, upd)
+		case "crop":
+			return b.handleCrop(ctx, upd)
+		case "mode":
+			return b.handleMode(chatID, upd.Message.CommandArguments())
+		case "automate":
+			return b.handleAutomate(upd.Message)
+		case "format":
+			return b.handleFormat(chatID, upd.Message.CommandArguments())
+		case "redact":
+			return b.handleRedact(chatID, upd.Message.CommandArguments())
+		case "report":
+			return b.handleReport(chatID, upd.Message.MessageID)
+		case "review":
+			return b.handleReview(ctx, chatID, upd.Message.CommandArguments())
+		case "cancel":
+			return b.handleCancel(chatID)
+		case "done":
+			return b.finishInterview(ctx, chatID)
+		case "skip":
+			if iv := b.interviews.get(b.session(chatID)); iv != nil {
+				return b.skipInterviewStep(ctx, chatID, iv)
+			}
+			return b.handleSkip(ctx, chatID)
 		default:
-			return b.sendText(chatID, "Unknown command. Use /start, /describe or /help. You can also send a photo or a text bug description.")
+			if isGroupChat(upd.Message.Chat) {
+				// Команди інших ботів у групі без @username.
+				return nil
+			}
+			return b.sendText(chatID, "Unknown command. Use /start, /describe, /project or /help. You can also send a photo or a text bug description.")
 		}
 	}
 
+	// Під час інтерв'ю /report усі повідомлення — відповіді на його питання.
+	if iv := b.interviews.get(b.session(chatID)); iv != nil {
+		return b.continueInterview(ctx, upd.Message, iv)
+	}
+	// Після імпорту набору тест-кейсів наступний звіт або скріншот — те, щодо чого їх перевірити.
+	if b.suites.get(b.session(chatID)) != nil && (upd.Message.Document == nil || isImageDocument(upd.Message.Document)) {
+		return b.continueSuiteReview(ctx, upd.Message)
+	}
+
 	// Reply to "Edit" prompt → regenerate test cases from the reply text.
 	if upd.Message.ReplyToMessage != nil && upd.Message.ReplyToMessage.From != nil && upd.Message.ReplyToMessage.From.IsBot {
 		if strings.TrimSpace(upd.Message.ReplyToMessage.Text) == editPromptText {
+			if gherkin.LooksLikeFeature(upd.Message.Text) {
+				return b.importFeature(ctx, chatID, upd.Message.Text, b.resultFor(chatID, upd.Message.ReplyToMessage.MessageID))
+			}
 			return b.handleEdit(ctx, upd)
 		}
 	}
@@ -87,6 +298,17 @@ func (b *Bot) handleUpdate(ctx context.Context, upd *tgbotapi.Update) error {
 	if upd.Message.Document != nil && isImageDocument(upd.Message.Document) {
 		return b.handleDocument(ctx, upd)
 	}
+	if upd.Message.Document != nil && isFeatureDocument(upd.Message.Document) {
+		return b.handleFeatureDocument(ctx, upd.Message)
+	}
+	if upd.Message.Document != nil && isTestSuiteDocument(upd.Message.Document) {
+		return b.handleSuiteDocument(ctx, upd.Message)
+	}
+
+	// Текст у форматі Gherkin — відредаговані сценарії, а не новий опис бага.
+	if gherkin.LooksLikeFeature(upd.Message.Text) {
+		return b.importFeature(ctx, chatID, upd.Message.Text, nil)
+	}
 
 	if txt := strings.TrimSpace(upd.Message.Text); txt != "" {
 		return b.handleText(ctx, upd)
@@ -114,13 +336,195 @@ func (b *Bot) handleHelp(chatID int64) error {
 	text := "Commands\n\n" +
 		"• /start — welcome and how to use the bot\n" +
 		"• /describe — hint for describing a bug in text\n" +
+		"• /project <name> [prefix] — set the project for this chat; test case IDs are numbered per project (e.g. LOGIN-TC-042)\n" +
+		"• /mode bug|regression|negative|boundary|full — which test cases to generate besides reproducing the bug\n" +
+		"• /reanalyze — reply to a screenshot to analyze it again instead of using the cached result\n" +
+		"• /crop <x> <y> <w> <h> — reply to a screenshot to analyze only that region (percent of the image)\n" +
+		"• /format text|gherkin — send test cases as plain text or as Gherkin (Feature / Scenario / Given / When / Then)\n" +
+		"• /automate playwright|cypress|chromedp — turn the latest test cases (or the ones whose \"Edit\" message you reply to) into an automated test skeleton\n" +
+		"• /redact on|off — blur emails, phone and card numbers on screenshots before analysis\n" +
+		"• /report — file a bug step by step: environment, app version, steps, expected and actual result, frequency, screenshots\n" +
+		"• /skip — when I ask clarifying questions, generate test cases from what you've written so far; in /report, skip an optional question\n" +
+		"• /review [bug report] — after you send a CSV or Markdown file with your test cases, review them for weak and missing cases\n" +
+		"• /cancel — stop the /report interview or drop imported test cases\n" +
+		"• /join <code> — get access to a private bot with an invite code from an admin\n" +
 		"• /help — this message\n\n" +
 		"Usage\n\n" +
 		"• Send a photo (screenshot) — I analyze the image and generate test cases.\n" +
 		"• Send text — describe the bug in your own words (any language); I generate test cases with priority and severity.\n\n" +
 		"Edit\n\n" +
-		"After you get test cases, I send an \"Edit\" message. Reply to it with your corrections or extra details, and I'll regenerate test cases from your text."
-	return b.sendText(chatID, text)
+		"After you get test cases, I send an \"Edit\" message. Reply to it with your corrections or extra details, and I'll regenerate test cases from your text. Regenerated test cases keep their IDs.\n\n" +
+		"Gherkin\n\n" +
+		"Send a .feature file or paste text starting with \"Feature:\" — I turn the scenarios back into test cases. Scenarios named \"TC-001: ...\" keep their IDs."
+	if b.access.enabled && b.access.role(b.origins.get(chatID).userID, chatID) == RoleAdmin {
+		text += "\n\nAdmin\n\n" +
+			"• /users — who has access and with which role\n" +
+			"• /adduser <id> [admin|tester|viewer] — grant access (or reply to the user's message with /adduser [role])\n" +
+			"• /removeuser <id> — revoke access (or reply to the user's message)\n" +
+			"• /invite [tester|viewer] [uses] — create an invite code and link\n" +
+			"• /quota [id] — analysis limits and today's usage (or reply to the user's message)"
+	}
+	return b.sendLongText(chatID, text)
+}
+
+var projectPrefixRe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// handleProject показує або змінює проєкт чату, від якого залежить нумерація тест-кейсів.
+func (b *Bot) handleProject(chatID int64, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		cs := b.settings.Get(chatID)
+		if cs.Project == "" {
+			return b.sendText(chatID, "No project set for this chat; test case IDs look like "+analysis.FormatTestCaseID(b.idPrefix, 1, false)+".\n\nUsage: /project <name> [prefix]")
+		}
+		return b.sendText(chatID, fmt.Sprintf("Project: %s\nNext IDs look like %s.", cs.Project, analysis.FormatTestCaseID(b.testCasePrefix(cs), 1, false)))
+	}
+
+	name := fields[0]
+	prefix := name
+	if len(fields) > 1 {
+		prefix = fields[1]
+	}
+	prefix = strings.Trim(projectPrefixRe.ReplaceAllString(strings.ToUpper(prefix), "-"), "-")
+	if prefix == "" {
+		return b.sendText(chatID, "Prefix must contain latin letters or digits, e.g. /project login LOGIN")
+	}
+
+	var cs ChatSettings
+	if err := b.settings.Update(chatID, func(s *ChatSettings) {
+		s.Project = name
+		s.IDPrefix = prefix
+		cs = *s
+	}); err != nil {
+		return err
+	}
+	return b.sendText(chatID, fmt.Sprintf("Project set to %s. Test case IDs will look like %s.", name, analysis.FormatTestCaseID(b.testCasePrefix(cs), 1, false)))
+}
+
+// projectKey повертає ключ нумерації: назву проєкту або ID чату, якщо проєкт не задано.
+func (b *Bot) projectKey(chatID int64) string {
+	if p := b.settings.Get(chatID).Project; p != "" {
+		return "project:" + strings.ToLower(p)
+	}
+	return "chat:" + strconv.FormatInt(chatID, 10)
+}
+
+// testCasePrefix поєднує префікс проєкту з базовим префіксом (LOGIN + TC → LOGIN-TC).
+func (b *Bot) testCasePrefix(cs ChatSettings) string {
+	if cs.IDPrefix == "" {
+		return b.idPrefix
+	}
+	return cs.IDPrefix + "-" + b.idPrefix
+}
+
+// analysisContext додає в ctx дані чату, потрібні аналізатору: проєкт (для правил пріоритетів) і режим генерації.
+func (b *Bot) analysisContext(ctx context.Context, chatID int64) context.Context {
+	cs := b.settings.Get(chatID)
+	ctx = analysis.WithProject(ctx, cs.Project)
+	if cs.Mode != "" {
+		ctx = analysis.WithGenerationMode(ctx, cs.Mode)
+	}
+	return ctx
+}
+
+// modeDescriptions — пояснення режимів для /mode.
+var modeDescriptions = map[analysis.GenerationMode]string{
+	analysis.ModeBug:        "only test cases that reproduce the reported bug",
+	analysis.ModeRegression: "plus regression cases for neighbouring functionality",
+	analysis.ModeNegative:   "plus negative cases: invalid input, permissions, errors",
+	analysis.ModeBoundary:   "plus boundary cases: empty values, min/max lengths, limits",
+	analysis.ModeFull:       "plus regression, negative and boundary cases",
+}
+
+// handleMode показує або змінює режим генерації тест-кейсів для чату.
+func (b *Bot) handleMode(chatID int64, args string) error {
+	var sb strings.Builder
+	for _, m := range analysis.GenerationModes {
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", m, modeDescriptions[m]))
+	}
+	list := sb.String()
+
+	if strings.TrimSpace(args) == "" {
+		current := b.settings.Get(chatID).Mode
+		if current == "" {
+			current = analysis.ModeBug
+		}
+		return b.sendText(chatID, fmt.Sprintf("Generation mode: %s\n\nUsage: /mode <mode>\n%s", current, list))
+	}
+	mode, ok := analysis.ParseGenerationMode(args)
+	if !ok {
+		return b.sendText(chatID, fmt.Sprintf("Unknown mode %q. Available modes:\n%s", strings.TrimSpace(args), list))
+	}
+	if err := b.settings.Update(chatID, func(s *ChatSettings) {
+		s.Mode = mode
+		if mode == analysis.ModeBug {
+			s.Mode = ""
+		}
+	}); err != nil {
+		return err
+	}
+	return b.sendText(chatID, fmt.Sprintf("Generation mode set to %s: %s.", mode, modeDescriptions[mode]))
+}
+
+// SetScrubber вмикає маскування персональних даних у описах користувачів і відповідях (nil — вимкнено).
+func (b *Bot) SetScrubber(s *pii.Scrubber) {
+	b.scrubber = s
+}
+
+// sendResult нумерує тест-кейси, надсилає результат з підписом header і повідомлення "Edit".
+// prev — попередній результат (при редагуванні), чиї ID потрібно зберегти.
+// tracked=false для шаблонів: вони не отримують ID з лічильника проєкту, не порівнюються з історією і не потрапляють у неї.
+func (b *Bot) sendResult(ctx context.Context, chatID int64, header string, res, prev *analysis.BugAnalysis, tracked bool) {
+	if tracked {
+		b.assignTestCaseIDs(chatID, prev, res)
+	}
+	res.MapText(b.scrubber.Scrub)
+	if tracked {
+		header = b.checkDuplicateTestCases(ctx, chatID, res) + header
+	}
+	header = b.attribution(chatID) + header
+	_ = b.sendLongText(chatID, header+b.formatResult(chatID, res))
+	promptID, err := b.sendTextWithID(chatID, editPromptText)
+	if err != nil || promptID == 0 {
+		return
+	}
+	b.resultsMu.Lock()
+	if len(b.results) >= maxStoredResults {
+		for k := range b.results {
+			delete(b.results, k)
+			break
+		}
+	}
+	b.results[messageKey{chatID, promptID}] = res
+	b.latest[chatID] = res
+	b.resultsMu.Unlock()
+}
+
+// assignTestCaseIDs нумерує тест-кейси res наступними ID проєкту, зберігаючи ID з prev.
+func (b *Bot) assignTestCaseIDs(chatID int64, prev, res *analysis.BugAnalysis) {
+	if err := b.ids.AssignStable(b.projectKey(chatID), b.testCasePrefix(b.settings.Get(chatID)), prev, res); err != nil {
+		b.logger(chatID).Warn("assign test case ids", "err", err)
+	}
+}
+
+// resultFor повертає результат, до якого належить повідомлення "Edit" (nil, якщо бот його вже не пам'ятає).
+func (b *Bot) resultFor(chatID int64, promptID int) *analysis.BugAnalysis {
+	b.resultsMu.Lock()
+	defer b.resultsMu.Unlock()
+	return b.results[messageKey{chatID, promptID}]
+}
+
+// targetResult — результат, до якого застосовується команда: той, на чиє повідомлення "Edit" відповіли,
+// або останній результат у чаті.
+func (b *Bot) targetResult(msg *tgbotapi.Message) *analysis.BugAnalysis {
+	if msg.ReplyToMessage != nil {
+		if res := b.resultFor(msg.Chat.ID, msg.ReplyToMessage.MessageID); res != nil {
+			return res
+		}
+	}
+	b.resultsMu.Lock()
+	defer b.resultsMu.Unlock()
+	return b.latest[msg.Chat.ID]
 }
 
 func (b *Bot) handlePhoto(ctx context.Context, upd *tgbotapi.Update) error {
@@ -131,43 +535,127 @@ func (b *Bot) handlePhoto(ctx context.Context, upd *tgbotapi.Update) error {
 
 	// Беремо найбільше за розміром фото.
 	fileID := photoSizes[len(photoSizes)-1].FileID
-	return b.processImageByFileID(ctx, upd.Message.Chat.ID, fileID)
+	return b.processImageByFileID(ctx, upd.Message, fileID)
 }
 
 func (b *Bot) handleDocument(ctx context.Context, upd *tgbotapi.Update) error {
 	fileID := upd.Message.Document.FileID
-	return b.processImageByFileID(ctx, upd.Message.Chat.ID, fileID)
+	return b.processImageByFileID(ctx, upd.Message, fileID)
 }
 
-func (b *Bot) processImageByFileID(ctx context.Context, chatID int64, fileID string) error {
-	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+// handleReanalyze повторно аналізує скріншот, на який відповів користувач, ігноруючи кеш.
+func (b *Bot) handleReanalyze(ctx context.Context, upd *tgbotapi.Update) error {
+	orig := upd.Message.ReplyToMessage
+	fileID := imageFileID(orig)
+	if fileID == "" {
+		return b.sendText(upd.Message.Chat.ID, "Reply with /reanalyze to the screenshot you want to analyze again.")
+	}
+	return b.processImageByFileID(analysis.WithForceRefresh(ctx), orig, fileID)
+}
+
+const cropUsage = "Reply to a screenshot with /crop <x> <y> <width> <height> in percent of the image, e.g. /crop 0 40 100 30 — analyze the horizontal band from 40% to 70% of the height."
+
+// handleCrop аналізує лише вказану частину скріншота, на який відповів користувач.
+func (b *Bot) handleCrop(ctx context.Context, upd *tgbotapi.Update) error {
+	chatID := upd.Message.Chat.ID
+	orig := upd.Message.ReplyToMessage
+	fileID := imageFileID(orig)
+	if fileID == "" {
+		return b.sendText(chatID, cropUsage)
+	}
+	region, err := parseCropArgs(upd.Message.CommandArguments())
 	if err != nil {
-		return b.sendText(chatID, "Не вдалося отримати файл з Telegram. Спробуйте, будь ласка, ще раз.")
+		return b.sendText(chatID, err.Error()+"\n\n"+cropUsage)
 	}
 
-	url := file.Link(b.api.Token)
-	resp, err := http.Get(url)
+	data, err := b.downloadImage(ctx, chatID, fileID)
+	if data == nil {
+		return err
+	}
+	cropped, err := analysis.CropImage(data, region)
 	if err != nil {
-		return b.sendText(chatID, "Помилка при завантаженні зображення. Спробуйте, будь ласка, ще раз.")
+		b.logger(chatID).Warn("crop image", "err", err)
+		return b.sendText(chatID, "Could not crop the screenshot: "+err.Error())
+	}
+	return b.analyzeImageData(ctx, orig, cropped)
+}
+
+// parseCropArgs розбирає "x y width height" у відсотках у відносний регіон.
+func parseCropArgs(args string) (analysis.Region, error) {
+	fields := strings.Fields(strings.NewReplacer("%", " ", ",", " ").Replace(args))
+	if len(fields) != 4 {
+		return analysis.Region{}, fmt.Errorf("Expected 4 numbers, got %d.", len(fields))
+	}
+	var v [4]float64
+	for i, f := range fields {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil || n < 0 || n > 100 {
+			return analysis.Region{}, fmt.Errorf("%q is not a percentage between 0 and 100.", f)
+		}
+		v[i] = n / 100
 	}
-	defer resp.Body.Close()
+	if v[2] == 0 || v[3] == 0 {
+		return analysis.Region{}, fmt.Errorf("Width and height must be greater than 0.")
+	}
+	return analysis.Region{X: v[0], Y: v[1], Width: v[2], Height: v[3]}, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return b.sendText(chatID, "Не вдалося завантажити зображення. Спробуйте, будь ласка, ще раз.")
+// imageFileID повертає file_id зображення з повідомлення (найбільше фото або image-документ) або "".
+func imageFileID(msg *tgbotapi.Message) string {
+	switch {
+	case msg == nil:
+		return ""
+	case len(msg.Photo) > 0:
+		return msg.Photo[len(msg.Photo)-1].FileID
+	case msg.Document != nil && isImageDocument(msg.Document):
+		return msg.Document.FileID
+	default:
+		return ""
 	}
+}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return b.sendText(chatID, "Помилка при читанні зображення. Спробуйте, будь ласка, ще раз.")
+func (b *Bot) processImageByFileID(ctx context.Context, msg *tgbotapi.Message, fileID string) error {
+	data, err := b.downloadImage(ctx, msg.Chat.ID, fileID)
+	if data == nil {
+		return err
+	}
+	return b.analyzeImageData(ctx, msg, data)
+}
+
+// analyzeImageData аналізує завантажений скріншот і надсилає результат; msg — повідомлення зі скріншотом.
+func (b *Bot) analyzeImageData(ctx context.Context, msg *tgbotapi.Message, data []byte) error {
+	chatID := msg.Chat.ID
+	// Ліміт списується лише тоді, коли аналізатор справді звертається до моделі: не за результат з кешу
+	// і не за скріншот, який відхилило маскування.
+	ctx = analysis.WithModelCallGate(ctx, func() bool { return b.allowAnalysis(chatID, kindImage, 1) })
+
+	data, ok := b.redactScreenshot(ctx, chatID, msg.MessageID, data)
+	if !ok {
+		return nil
 	}
 
+	bugQuery := history.BugQuery{Text: b.scrubber.Scrub(strings.TrimSpace(msg.Caption))}
+	if h, err := analysis.PerceptualHash(data); err == nil {
+		bugQuery.PHash, bugQuery.HasPHash = h, true
+	} else {
+		b.logger(chatID).Debug("perceptual hash", "err", err)
+	}
+	b.notifySimilarBugs(chatID, bugQuery)
+
 	progressMsgID, _ := b.sendTextWithID(chatID, "Analyzing your screenshot... (this may take 1–2 min)")
-	analysisResult, err := b.analyzer.Analyze(ctx, data)
+	analysisResult, err := b.analyzer.Analyze(b.analysisContext(ctx, chatID), data)
+	if errors.Is(err, analysis.ErrQuotaExceeded) {
+		// Про ліміт allowAnalysis уже відповів.
+		if progressMsgID != 0 {
+			_ = b.editMessage(chatID, progressMsgID, "Analysis skipped.")
+		}
+		return nil
+	}
 	if progressMsgID != 0 {
 		_ = b.editMessage(chatID, progressMsgID, "Analysis complete.")
 	}
 	if err != nil {
-		log.Printf("[DEBUG] Analyze(image) error: %v", err)
+		b.logger(chatID).Warn("analyze image failed, sending template", "err", err)
 		fallback := analysis.FallbackTemplate()
 		errHint := err.Error()
 		if len(errHint) > 200 {
@@ -178,90 +666,145 @@ func (b *Bot) processImageByFileID(ctx context.Context, chatID int64, fileID str
 			"• У .env: OLLAMA_MODEL=llava\n" +
 			"• Виконай один раз: ollama pull llava\n" +
 			"• Ollama має бути запущений (додаток або ollama serve)\n\n" +
-			"Шаблон, можна відредагувати:\n\n" + analysis.FormatBugAnalysis(fallback)
-		_ = b.sendLongText(chatID, msg)
-		_ = b.sendText(chatID, editPromptText)
+			"Шаблон, можна відредагувати:\n\n"
+		b.sendResult(ctx, chatID, msg, fallback, nil, false)
 		return nil
 	}
 
-	text := analysis.FormatBugAnalysis(analysisResult)
-	_ = b.sendLongText(chatID, text)
-	_ = b.sendText(chatID, editPromptText)
+	header := ""
+	if analysisResult.Cached {
+		header = "♻️ This screenshot was analyzed before — showing the cached result. Reply /reanalyze to the screenshot to run the analysis again.\n\n"
+	}
+	b.sendResult(ctx, chatID, header, analysisResult, nil, true)
+	b.sendAnnotatedScreenshot(chatID, data, analysisResult)
+	b.rememberBug(chatID, msg.MessageID, bugQuery, analysisResult)
 	return nil
 }
 
 func (b *Bot) handleEdit(ctx context.Context, upd *tgbotapi.Update) error {
 	chatID := upd.Message.Chat.ID
-	replyText := strings.TrimSpace(upd.Message.Text)
+	replyText := b.scrubber.Scrub(strings.TrimSpace(upd.Message.Text))
 	if replyText == "" {
 		return b.sendText(chatID, "Please reply with your corrections or extra details (non-empty text).")
 	}
+	if !b.allowAnalysis(chatID, kindText, 1) {
+		return nil
+	}
+	prev := b.resultFor(chatID, upd.Message.ReplyToMessage.MessageID)
 	progressMsgID, _ := b.sendTextWithID(chatID, "Regenerating test cases from your edit...")
-	result, err := b.analyzer.AnalyzeText(ctx, replyText)
+	result, err := b.analyzer.AnalyzeText(b.analysisContext(ctx, chatID), replyText)
 	if progressMsgID != 0 {
 		_ = b.editMessage(chatID, progressMsgID, "Analysis complete.")
 	}
 	if err != nil {
-		log.Printf("[DEBUG] AnalyzeText(edit) error: %v", err)
+		b.logger(chatID).Warn("analyze edit failed, using the text as is", "err", err)
 		fallback := analysis.FallbackFromUserDescription(replyText)
-		msg := "Test cases based on your edit (AI was unavailable):\n\n" + analysis.FormatBugAnalysis(fallback)
-		_ = b.sendLongText(chatID, msg)
-		_ = b.sendText(chatID, editPromptText)
+		b.sendResult(ctx, chatID, "Test cases based on your edit (AI was unavailable):\n\n", fallback, prev, true)
 		return nil
 	}
-	_ = b.sendLongText(chatID, analysis.FormatBugAnalysis(result))
-	_ = b.sendText(chatID, editPromptText)
+	b.sendResult(ctx, chatID, "", result, prev, true)
 	return nil
 }
 
 func (b *Bot) handleText(ctx context.Context, upd *tgbotapi.Update) error {
 	chatID := upd.Message.Chat.ID
-	desc := strings.TrimSpace(upd.Message.Text)
+	desc := b.scrubber.Scrub(strings.TrimSpace(upd.Message.Text))
 	if desc == "" {
 		return b.sendText(chatID, "Please provide a non-empty bug description or send a screenshot.")
 	}
 
+	messageID := upd.Message.MessageID
+	if b.qualityGate {
+		var ready bool
+		desc, messageID, ready = b.gateDescription(upd.Message, desc)
+		if !ready {
+			return nil
+		}
+	}
+	return b.analyzeDescription(ctx, chatID, messageID, desc)
+}
+
+// analyzeDescription генерує тест-кейси з текстового опису; messageID — повідомлення, з якого почався опис.
+func (b *Bot) analyzeDescription(ctx context.Context, chatID int64, messageID int, desc string) error {
+	if !b.allowAnalysis(chatID, kindText, 1) {
+		return nil
+	}
+	bugQuery := history.BugQuery{Text: desc}
+	b.notifySimilarBugs(chatID, bugQuery)
+
 	progressMsgID, _ := b.sendTextWithID(chatID, "Analyzing your description...")
-	analysisResult, err := b.analyzer.AnalyzeText(ctx, desc)
+	analysisResult, err := b.analyzer.AnalyzeText(b.analysisContext(ctx, chatID), desc)
 	if progressMsgID != 0 {
 		_ = b.editMessage(chatID, progressMsgID, "Analysis complete.")
 	}
 	if err != nil {
-		log.Printf("[DEBUG] AnalyzeText error: %v", err)
+		b.logger(chatID).Warn("analyze text failed, using the description as is", "err", err)
 		fallback := analysis.FallbackFromUserDescription(desc)
-		msg := "Test cases based on your description (AI was unavailable; start Ollama for full analysis):\n\n" + analysis.FormatBugAnalysis(fallback)
-		_ = b.sendLongText(chatID, msg)
-		_ = b.sendText(chatID, editPromptText)
+		b.sendResult(ctx, chatID, "Test cases based on your description (AI was unavailable; start Ollama for full analysis):\n\n", fallback, nil, true)
+		b.rememberBug(chatID, messageID, bugQuery, fallback)
 		return nil
 	}
 
-	text := analysis.FormatBugAnalysis(analysisResult)
-	_ = b.sendLongText(chatID, text)
-	_ = b.sendText(chatID, editPromptText)
+	b.sendResult(ctx, chatID, "", analysisResult, nil, true)
+	b.rememberBug(chatID, messageID, bugQuery, analysisResult)
 	return nil
 }
 
 func (b *Bot) sendText(chatID int64, text string) error {
-	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := b.api.Send(msg)
+	_, err := b.sendMessage(chatID, text, nil)
 	return err
 }
 
 // sendTextWithID sends a message and returns its ID (or 0 on failure), so it can be edited for progress.
 func (b *Bot) sendTextWithID(chatID int64, text string) (int, error) {
-	msg := tgbotapi.NewMessage(chatID, text)
-	sent, err := b.api.Send(msg)
+	id, err := b.sendMessage(chatID, text, nil)
 	if err != nil {
 		return 0, err
 	}
-	return sent.MessageID, nil
+	return id, nil
+}
+
+// sendPhoto sends an image (PNG/JPEG bytes) with an optional caption.
+func (b *Bot) sendPhoto(chatID int64, name string, data []byte, caption string) error {
+	return b.sendFile(chatID, "sendPhoto", "photo", name, data, caption)
+}
+
+// sendAnnotatedScreenshot надсилає скріншот з пронумерованими рамками навколо проблемних елементів
+// (номер рамки = номер тест-кейсу у відповіді). Нічого не робить, якщо модель не повернула регіонів.
+func (b *Bot) sendAnnotatedScreenshot(chatID int64, data []byte, res *analysis.BugAnalysis) {
+	if !analysis.HasRegions(res) {
+		return
+	}
+	annotated, err := analysis.AnnotateScreenshot(data, res.TestCases)
+	if err != nil {
+		b.logger(chatID).Warn("annotate screenshot", "err", err)
+		return
+	}
+	var legend []string
+	for i, tc := range res.TestCases {
+		if tc.Region != nil {
+			legend = append(legend, fmt.Sprintf("%d — %s", i+1, tc.ID))
+		}
+	}
+	caption := "Problem areas: " + strings.Join(legend, ", ")
+	if err := b.sendPhoto(chatID, "annotated.png", annotated, caption); err != nil {
+		b.logger(chatID).Warn("send annotated screenshot", "err", err)
+	}
+}
+
+// sendDocument sends a file with an optional caption.
+func (b *Bot) sendDocument(chatID int64, name string, data []byte, caption string) error {
+	return b.sendFile(chatID, "sendDocument", "document", name, data, caption)
 }
 
 // editMessage updates an existing message (e.g. progress "Analyzing..." -> "Analysis complete.").
 func (b *Bot) editMessage(chatID int64, messageID int, text string) error {
+	span := b.traceTelegram(chatID, "editMessageText")
+	defer span.End()
 	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
 	_, err := b.api.Send(edit)
-	return err
+	span.RecordError(err)
+	return recordSendError("editMessageText", err)
 }
 
 // sendLongText надсилає текст частинами, щоб не перевищити ліміт Telegram 4096 символів.
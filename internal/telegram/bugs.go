@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/history"
+)
+
+// maxSimilarBugs — скільки схожих багів показувати перед генерацією тест-кейсів.
+const maxSimilarBugs = 3
+
+// notifySimilarBugs шукає в історії проєкту схожі баги і, якщо знайшов, надсилає їх списком з посиланнями.
+func (b *Bot) notifySimilarBugs(chatID int64, q history.BugQuery) {
+	matches := b.history.SimilarBugs(b.projectKey(chatID), q, b.bugThreshold, b.phashMaxDistance, maxSimilarBugs)
+	if len(matches) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString("🔁 This bug may have been reported already:\n")
+	for i, m := range matches {
+		title := m.Record.BugTitle
+		if m.Record.Component != "" {
+			title = "[" + m.Record.Component + "] " + title
+		}
+		sb.WriteString(fmt.Sprintf("%d) %s — %s", i+1, title, history.Ago(m.Record.CreatedAt, now)))
+		if m.Record.ReportedBy != "" {
+			sb.WriteString(" by " + m.Record.ReportedBy)
+		}
+		var why []string
+		if m.Distance >= 0 && m.Distance <= b.phashMaxDistance {
+			why = append(why, "same-looking screenshot")
+		}
+		if m.Similarity >= b.bugThreshold {
+			why = append(why, fmt.Sprintf("%.0f%% similar text", m.Similarity*100))
+		}
+		if len(why) > 0 {
+			sb.WriteString(" (" + strings.Join(why, ", ") + ")")
+		}
+		sb.WriteString("\n")
+		if len(m.Record.TestCaseIDs) > 0 {
+			sb.WriteString("   Test cases: " + strings.Join(m.Record.TestCaseIDs, ", ") + "\n")
+		}
+		if link := messageLink(m.Record.ChatID, m.Record.MessageID); link != "" {
+			sb.WriteString("   " + link + "\n")
+		}
+	}
+	sb.WriteString("\nGenerating new test cases anyway...")
+	_ = b.sendText(chatID, sb.String())
+}
+
+// rememberBug зберігає звіт в історії, щоб наступні звіти можна було з ним порівняти.
+func (b *Bot) rememberBug(chatID int64, messageID int, q history.BugQuery, res *analysis.BugAnalysis) {
+	if res == nil {
+		return
+	}
+	rec := history.BugRecord{
+		BugTitle:    res.BugTitle,
+		Description: q.Text,
+		Component:   res.Component,
+		Tags:        res.Tags,
+		PHash:       q.PHash,
+		HasPHash:    q.HasPHash,
+		ChatID:      chatID,
+		MessageID:   messageID,
+		ReportedBy:  b.origins.get(chatID).author,
+		CreatedAt:   time.Now(),
+	}
+	for _, tc := range res.TestCases {
+		rec.TestCaseIDs = append(rec.TestCaseIDs, tc.ID)
+	}
+	if err := b.history.AddBug(b.projectKey(chatID), rec); err != nil {
+		b.logger(chatID).Warn("save bug history", "err", err)
+	}
+}
+
+// messageLink будує посилання на повідомлення. Telegram дає посилання лише для супергруп/каналів
+// (ID виду -100XXXXXXXXXX → https://t.me/c/XXXXXXXXXX/<msg>); для інших чатів повертає порожній рядок.
+func messageLink(chatID int64, messageID int) string {
+	id := strconv.FormatInt(chatID, 10)
+	if !strings.HasPrefix(id, "-100") || messageID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(id, "-100"), messageID)
+}
@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/tracing"
+)
+
+// errFileTooLarge — файл більший за дозволений розмір; такі файли не завантажуються далі ліміту.
+var errFileTooLarge = errors.New("file is too large")
+
+const (
+	// downloadAttempts — скільки разів пробувати завантажити файл, якщо Telegram відповідає 5xx або рве з'єднання.
+	downloadAttempts = 3
+	// downloadBackoff — пауза перед другою спробою; далі вона подвоюється.
+	downloadBackoff = 500 * time.Millisecond
+	// downloadTimeout обмежує одну спробу завантаження.
+	downloadTimeout = 60 * time.Second
+)
+
+// downloader завантажує файли з файлового сервера Telegram з лімітом розміру і повторами на тимчасових помилках.
+type downloader struct {
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+func newDownloader() *downloader {
+	return &downloader{
+		client:   &http.Client{Timeout: downloadTimeout},
+		attempts: downloadAttempts,
+		backoff:  downloadBackoff,
+	}
+}
+
+// retryableError — помилка, після якої варто спробувати ще раз (5xx, обірване з'єднання).
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string { return e.err.Error() }
+func (e retryableError) Unwrap() error { return e.err }
+
+// fetch завантажує url, читаючи не більше maxBytes; attempts — скільки спроб знадобилось.
+func (d *downloader) fetch(ctx context.Context, url string, maxBytes int64) (data []byte, attempts int, err error) {
+	wait := d.backoff
+	for attempts = 1; ; attempts++ {
+		data, err = d.fetchOnce(ctx, url, maxBytes)
+		var retry retryableError
+		if err == nil || !errors.As(err, &retry) || attempts >= d.attempts || ctx.Err() != nil {
+			return data, attempts, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, attempts, ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+func (d *downloader) fetchOnce(ctx context.Context, url string, maxBytes int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create download request: %w", err)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// URL містить токен бота — у помилку він потрапити не повинен.
+		return nil, retryableError{fmt.Errorf("download file: %w", errors.Unwrap(err))}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, retryableError{fmt.Errorf("download file: status %s", resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("download file: status %s", resp.Status)
+	case resp.ContentLength > maxBytes:
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", errFileTooLarge, resp.ContentLength, maxBytes)
+	}
+
+	// Читаємо на байт більше ліміту, щоб відрізнити файл рівно в ліміт від більшого, не тримаючи в пам'яті зайве.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, retryableError{fmt.Errorf("read file: %w", err)}
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", errFileTooLarge, maxBytes)
+	}
+	return data, nil
+}
+
+// downloadFile завантажує файл з Telegram, не більше maxBytes; більші файли дають errFileTooLarge.
+// Розмір і SHA-256 завантаженого файла пишуться в лог, щоб зіставити звіт з оригіналом. Відмови теж
+// пишуться в лог тут, тож викликачі лише відповідають користувачу.
+func (b *Bot) downloadFile(ctx context.Context, chatID int64, fileID string, maxBytes int64) (_ []byte, err error) {
+	ctx, span := tracing.StartClient(ctx, "telegram.download_file", tracing.Int64("max_bytes", maxBytes))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case errors.Is(err, errFileTooLarge):
+			b.logger(chatID).Warn("file rejected", "err", err)
+		default:
+			b.logger(chatID).Warn("file download failed", "err", err)
+		}
+	}()
+
+	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	if int64(file.FileSize) > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", errFileTooLarge, file.FileSize, maxBytes)
+	}
+
+	start := time.Now()
+	data, attempts, err := b.downloads.fetch(ctx, file.Link(b.api.Token), maxBytes)
+	span.SetAttributes(tracing.Int("attempts", attempts))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	span.SetAttributes(tracing.Int("bytes", len(data)), tracing.String("sha256", checksum))
+	b.logger(chatID).Info("file downloaded", "file_path", file.FilePath, "bytes", len(data), "sha256", checksum,
+		"attempts", attempts, "duration", time.Since(start).Round(time.Millisecond))
+	return data, nil
+}
+
+// fileTooLarge — швидкий шлях для документів: розмір відомий з повідомлення, тож завеликий файл
+// відхиляється без звернення до Telegram. Відмова пишеться в лог так само, як у downloadFile.
+func (b *Bot) fileTooLarge(chatID int64, size, maxBytes int64) bool {
+	if size <= maxBytes {
+		return false
+	}
+	b.logger(chatID).Warn("file rejected", "err", fmt.Errorf("%w: %d bytes, limit %d", errFileTooLarge, size, maxBytes))
+	return true
+}
+
+// downloadImage завантажує скріншот з Telegram. При невдачі сам повідомляє користувача і повертає nil-дані
+// разом із результатом надсилання цього повідомлення.
+func (b *Bot) downloadImage(ctx context.Context, chatID int64, fileID string) ([]byte, error) {
+	data, err := b.downloadFile(ctx, chatID, fileID, b.maxImageBytes)
+	switch {
+	case err == nil:
+		return data, nil
+	case errors.Is(err, errFileTooLarge):
+		return nil, b.sendText(chatID, fmt.Sprintf("Зображення завелике (максимум %s). Надішліть, будь ласка, менший скріншот: обріжте його або надішліть як фото, а не файлом.", formatSize(b.maxImageBytes)))
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	default:
+		return nil, b.sendText(chatID, "Не вдалося завантажити зображення. Спробуйте, будь ласка, ще раз.")
+	}
+}
+
+// formatSize показує розмір у KB або MB для повідомлень користувачу.
+func formatSize(n int64) string {
+	if n >= 1<<20 && n%(1<<20) == 0 {
+		return fmt.Sprintf("%d MB", n>>20)
+	}
+	if n >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", n>>10)
+}
@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloaderFetch(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/flaky":
+			if calls < 3 {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte("image"))
+		case "/big":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	d := &downloader{client: srv.Client(), attempts: downloadAttempts, backoff: time.Millisecond}
+
+	tests := []struct {
+		path         string
+		wantData     string
+		wantAttempts int
+		wantTooLarge bool
+		wantErr      bool
+	}{
+		{path: "/flaky", wantData: "image", wantAttempts: 3},
+		{path: "/big", wantAttempts: 1, wantTooLarge: true, wantErr: true},
+		{path: "/missing", wantAttempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = 0
+			data, attempts, err := d.fetch(context.Background(), srv.URL+tt.path, 10)
+			if (err != nil) != tt.wantErr || errors.Is(err, errFileTooLarge) != tt.wantTooLarge {
+				t.Fatalf("err = %v, wantErr %v, wantTooLarge %v", err, tt.wantErr, tt.wantTooLarge)
+			}
+			if string(data) != tt.wantData || attempts != tt.wantAttempts {
+				t.Errorf("data, attempts = %q, %d; want %q, %d", data, attempts, tt.wantData, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{20 << 20: "20 MB", 3 << 19: "1.5 MB", 512 << 10: "512 KB"} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/history"
+)
+
+// SetEmbedder вмикає семантичне порівняння тест-кейсів через ембединги (nil — лише токенна подібність).
+func (b *Bot) SetEmbedder(e analysis.Embedder) {
+	b.embedder = e
+}
+
+// embed повертає ембединг тексту або nil, якщо ембединги вимкнені чи недоступні.
+func (b *Bot) embed(ctx context.Context, text string) []float64 {
+	if b.embedder == nil {
+		return nil
+	}
+	v, err := b.embedder.Embed(ctx, text)
+	if err != nil {
+		slog.WarnContext(ctx, "embed test case", "err", err)
+		return nil
+	}
+	return v
+}
+
+// checkDuplicateTestCases порівнює нові тест-кейси з історією проєкту, запам'ятовує їх
+// і повертає попередження про схожі кейси (порожній рядок, якщо схожих немає).
+func (b *Bot) checkDuplicateTestCases(ctx context.Context, chatID int64, res *analysis.BugAnalysis) string {
+	project := b.projectKey(chatID)
+	now := time.Now()
+
+	var warnings []string
+	recs := make([]history.TestCaseRecord, 0, len(res.TestCases))
+	for _, tc := range res.TestCases {
+		emb := b.embed(ctx, analysis.TestCaseText(tc))
+		matches := b.history.SimilarTestCases(project, tc, emb, b.dupThreshold, b.dupEmbedThreshold)
+		if len(matches) > 0 {
+			m := matches[0]
+			warnings = append(warnings, fmt.Sprintf("• %s looks like %s from %s (%.0f%% similar): %s",
+				tc.ID, m.Record.ID, history.Ago(m.Record.CreatedAt, now), m.Similarity*100, m.Record.Title))
+		}
+		recs = append(recs, history.TestCaseRecord{
+			ID:        tc.ID,
+			Title:     tc.Title,
+			Expected:  tc.Expected,
+			Actual:    tc.Actual,
+			ChatID:    chatID,
+			CreatedAt: now,
+			Embedding: emb,
+		})
+	}
+	if err := b.history.AddTestCases(project, recs...); err != nil {
+		b.logger(chatID).Warn("save test case history", "err", err)
+	}
+
+	if len(warnings) == 0 {
+		return ""
+	}
+	return "⚠️ Possible duplicates of earlier test cases — check before filing:\n" + strings.Join(warnings, "\n") + "\n\n"
+}
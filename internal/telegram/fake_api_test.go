@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeTelegram — Bot API, що відповідає успіхом на будь-який метод і запам'ятовує тексти надісланих повідомлень.
+type fakeTelegram struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (f *fakeTelegram) texts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+// newFakeAPI піднімає fakeTelegram і повертає клієнт tgbotapi, налаштований на нього.
+func newFakeAPI(t *testing.T) (*tgbotapi.BotAPI, *fakeTelegram) {
+	t.Helper()
+	f := &fakeTelegram{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		var result any = map[string]any{"message_id": 1, "date": 0, "chat": map[string]any{"id": 1, "type": "private"}}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			result = map[string]any{"id": 42, "is_bot": true, "first_name": "Bot", "username": "TestBot"}
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			f.mu.Lock()
+			f.sent = append(f.sent, r.Form.Get("text"))
+			f.mu.Unlock()
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+	api, err := tgbotapi.NewBotAPIWithClient("TOKEN", srv.URL+"/bot%s/%s", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api, f
+}
+
+// newTestBot — Bot з фейковим Bot API і лише тими полями, які потрібні тестам доступу та лімітів.
+func newTestBot(t *testing.T) (*Bot, *fakeTelegram) {
+	t.Helper()
+	api, f := newFakeAPI(t)
+	return &Bot{api: api, origins: origins{byChat: make(map[int64]origin)}}, f
+}
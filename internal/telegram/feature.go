@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/gherkin"
+)
+
+// formatGherkin — значення ChatSettings.Format для відповіді BDD-сценаріями.
+const formatGherkin = "gherkin"
+
+// maxFeatureFileSize — найбільший .feature-файл, який бот приймає для імпорту.
+const maxFeatureFileSize = 256 << 10
+
+// formatResult рендерить результат у форматі, вибраному в чаті командою /format.
+func (b *Bot) formatResult(chatID int64, res *analysis.BugAnalysis) string {
+	if b.settings.Get(chatID).Format == formatGherkin {
+		return gherkin.Render(res)
+	}
+	return analysis.FormatBugAnalysis(res)
+}
+
+// handleFormat показує або змінює формат відповіді чату.
+func (b *Bot) handleFormat(chatID int64, args string) error {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	switch arg {
+	case "":
+		current := b.settings.Get(chatID).Format
+		if current == "" {
+			current = "text"
+		}
+		return b.sendText(chatID, fmt.Sprintf("Output format: %s\n\nUsage: /format text|gherkin", current))
+	case "text", "plain", formatGherkin, "bdd", "feature":
+	default:
+		return b.sendText(chatID, fmt.Sprintf("Unknown format %q. Use /format text or /format gherkin.", strings.TrimSpace(args)))
+	}
+
+	format := ""
+	if arg == formatGherkin || arg == "bdd" || arg == "feature" {
+		format = formatGherkin
+	}
+	if err := b.settings.Update(chatID, func(s *ChatSettings) {
+		s.Format = format
+	}); err != nil {
+		return err
+	}
+	if format == formatGherkin {
+		return b.sendText(chatID, "Test cases will be sent as Gherkin scenarios. Edit them and send the text or a .feature file back to update the test cases.")
+	}
+	return b.sendText(chatID, "Test cases will be sent as plain text.")
+}
+
+// isFeatureDocument — документ з Gherkin-сценаріями (.feature).
+func isFeatureDocument(doc *tgbotapi.Document) bool {
+	return doc != nil && strings.HasSuffix(strings.ToLower(doc.FileName), ".feature")
+}
+
+// handleFeatureDocument завантажує .feature-файл і імпортує з нього тест-кейси.
+func (b *Bot) handleFeatureDocument(ctx context.Context, msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	tooLarge := fmt.Sprintf("The .feature file is too large (max %d KB).", maxFeatureFileSize>>10)
+	if b.fileTooLarge(chatID, int64(msg.Document.FileSize), maxFeatureFileSize) {
+		return b.sendText(chatID, tooLarge)
+	}
+	data, err := b.downloadFile(ctx, chatID, msg.Document.FileID, maxFeatureFileSize)
+	if errors.Is(err, errFileTooLarge) {
+		return b.sendText(chatID, tooLarge)
+	}
+	if err != nil {
+		return b.sendText(chatID, "Could not download the .feature file. Please try again.")
+	}
+	if !utf8.Valid(data) {
+		return b.sendText(chatID, "The .feature file must be UTF-8 text.")
+	}
+	return b.importFeature(ctx, chatID, string(data), nil)
+}
+
+// importFeature перетворює Gherkin-сценарії на тест-кейси і надсилає їх як звичайний результат.
+// Сценарії з ID у назві ("TC-001: ...") зберігають свої ID; prev — результат, до "Edit" якого відповіли.
+func (b *Bot) importFeature(ctx context.Context, chatID int64, text string, prev *analysis.BugAnalysis) error {
+	res, err := gherkin.Parse(text)
+	if err != nil {
+		return b.sendText(chatID, "Could not read the Gherkin scenarios: "+err.Error()+"\n\nExpected: Feature: <title>, then Scenario: <title> blocks with Given / When / Then steps.")
+	}
+	analysis.NormalizeScales(res, nil, b.settings.Get(chatID).Project)
+	if keep := importedIDs(res); keep != nil {
+		prev = keep
+	}
+	// Імпорт не потрапляє в історію, але його сценарії — справжні тест-кейси, тож ID вони отримують.
+	b.assignTestCaseIDs(chatID, prev, res)
+	header := fmt.Sprintf("Imported %d scenarios from Gherkin.\n\n", len(res.TestCases))
+	b.sendResult(ctx, chatID, header, res, nil, false)
+	return nil
+}
+
+// importedIDs повертає результат-шаблон з ID, вказаними у сценаріях, для AssignStable.
+// ID беруться лише з початкових сценаріїв, що мають ID: AssignStable перевикористовує ID за позицією.
+func importedIDs(res *analysis.BugAnalysis) *analysis.BugAnalysis {
+	keep := &analysis.BugAnalysis{}
+	for _, tc := range res.TestCases {
+		if tc.ID == "" {
+			break
+		}
+		keep.TestCases = append(keep.TestCases, analysis.TestCase{ID: tc.ID})
+	}
+	if len(keep.TestCases) == 0 {
+		return nil
+	}
+	return keep
+}
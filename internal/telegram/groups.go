@@ -0,0 +1,322 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/logging"
+	"bugreportbot/internal/tracing"
+)
+
+// origin — звідки прийшло повідомлення, яке бот зараз обробляє: тема форуму, тип чату й автор.
+// Апдейти обробляються по одному, тож origin чату — це завжди автор і тема поточного запиту.
+type origin struct {
+	threadID  int
+	messageID int
+	group     bool
+	userID    int64
+	author    string
+	// correlationID позначає логи обробки цього повідомлення; trace — його спан, до якого додаються спани відповідей.
+	correlationID string
+	trace         tracing.SpanContext
+}
+
+// origins зберігає origin останнього повідомлення кожного чату.
+type origins struct {
+	mu     sync.Mutex
+	byChat map[int64]origin
+}
+
+func (o *origins) get(chatID int64) origin {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.byChat[chatID]
+}
+
+func (o *origins) set(chatID int64, or origin) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.byChat[chatID] = or
+}
+
+// sessionKey — ключ діалогового стану (чернетки, інтерв'ю, імпортовані набори): у групах у кожного учасника свій.
+type sessionKey struct {
+	chatID int64
+	userID int64
+}
+
+// session повертає ключ сесії автора поточного повідомлення в чаті.
+func (b *Bot) session(chatID int64) sessionKey {
+	return sessionKey{chatID: chatID, userID: b.origins.get(chatID).userID}
+}
+
+// logger повертає логер з чатом, автором і correlation ID повідомлення, яке бот зараз обробляє в чаті.
+func (b *Bot) logger(chatID int64) *slog.Logger {
+	o := b.origins.get(chatID)
+	l := slog.Default().With("chat_id", chatID)
+	if o.userID != 0 && o.userID != chatID {
+		l = l.With("user_id", o.userID)
+	}
+	if o.correlationID != "" {
+		l = l.With("correlation_id", o.correlationID)
+	}
+	return l
+}
+
+// traceTelegram починає спан виклику Telegram API method як дочірній до спану поточного повідомлення чату.
+func (b *Bot) traceTelegram(chatID int64, method string) *tracing.Span {
+	ctx := tracing.ContextWithSpanContext(context.Background(), b.origins.get(chatID).trace)
+	_, span := tracing.StartClient(ctx, "telegram."+method, tracing.Int64("chat_id", chatID))
+	return span
+}
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// authorName — як підписати автора звіту: @username або ім'я.
+func authorName(u *tgbotapi.User) string {
+	if u == nil {
+		return ""
+	}
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
+
+// newOrigin описує повідомлення msg з теми threadID; correlation ID і спан обробки беруться з ctx.
+func newOrigin(ctx context.Context, msg *tgbotapi.Message, threadID int) origin {
+	o := origin{
+		threadID:      threadID,
+		messageID:     msg.MessageID,
+		group:         isGroupChat(msg.Chat),
+		userID:        msg.Chat.ID,
+		correlationID: logging.CorrelationID(ctx),
+		trace:         tracing.SpanContextFromContext(ctx),
+	}
+	if msg.From != nil {
+		o.userID = msg.From.ID
+		o.author = authorName(msg.From)
+	}
+	return o
+}
+
+// addressPatterns будує регулярні вирази для згадки бота і хештегу груп (nil, якщо їх немає).
+func addressPatterns(username, hashtag string) (mention, tag *regexp.Regexp) {
+	if username != "" {
+		mention = regexp.MustCompile(`(?i)@` + regexp.QuoteMeta(username) + `\b[:,]?`)
+	}
+	if hashtag != "" {
+		tag = regexp.MustCompile(`(?i)(^|\s)` + regexp.QuoteMeta(hashtag) + `[:,.!]?($|[^\p{L}\p{N}_])`)
+	}
+	return mention, tag
+}
+
+// addressedToBot вирішує, чи реагувати на повідомлення в групі: команди цьому боту, згадки, відповіді на
+// повідомлення бота і хештег звіту.
+func (b *Bot) addressedToBot(msg *tgbotapi.Message) bool {
+	if msg.IsCommand() {
+		cmd := msg.CommandWithAt()
+		i := strings.Index(cmd, "@")
+		return i < 0 || strings.EqualFold(cmd[i+1:], b.api.Self.UserName)
+	}
+	if r := msg.ReplyToMessage; r != nil && r.From != nil && r.From.ID == b.api.Self.ID {
+		return true
+	}
+	text := msg.Text + "\n" + msg.Caption
+	if b.mentionRe != nil && b.mentionRe.MatchString(text) {
+		return true
+	}
+	if b.hashtagRe != nil && b.hashtagRe.MatchString(text) {
+		return true
+	}
+	// Незавершений діалог (інтерв'ю, уточнення, імпорт) продовжується лише відповіддю на питання бота:
+	// інакше бот підхоплював би будь-яку репліку автора в загальній розмові.
+	return false
+}
+
+// stripAddress прибирає з тексту згадку бота і хештег звіту, щоб вони не потрапили в опис бага.
+func (b *Bot) stripAddress(text string) string {
+	if b.mentionRe != nil {
+		text = b.mentionRe.ReplaceAllString(text, "")
+	}
+	if b.hashtagRe != nil {
+		// Пробіл перед хештегом і символ після нього лишаються.
+		text = b.hashtagRe.ReplaceAllString(text, "${1}${2}")
+	}
+	return strings.TrimSpace(text)
+}
+
+// attribution — підпис автора до результату в групі ("" в особистих чатах).
+func (b *Bot) attribution(chatID int64) string {
+	if o := b.origins.get(chatID); o.group && o.author != "" {
+		return "👤 Reported by " + o.author + "\n\n"
+	}
+	return ""
+}
+
+// incomingUpdate — апдейт разом із темою форуму, з якої він прийшов.
+type incomingUpdate struct {
+	update   tgbotapi.Update
+	threadID int
+}
+
+// topicFields — поля повідомлення про теми форуму, яких немає в telegram-bot-api v5.5.1.
+type topicFields struct {
+	Message *struct {
+		MessageThreadID int  `json:"message_thread_id"`
+		IsTopicMessage  bool `json:"is_topic_message"`
+	} `json:"message"`
+}
+
+// pollUpdates — аналог GetUpdatesChan, що додатково читає message_thread_id, щоб відповідати в ту саму тему.
+func (b *Bot) pollUpdates(ctx context.Context) <-chan incomingUpdate {
+	ch := make(chan incomingUpdate, 100)
+	go func() {
+		defer close(ch)
+		cfg := tgbotapi.NewUpdate(0)
+		cfg.Timeout = 60
+		for ctx.Err() == nil {
+			resp, err := b.api.Request(cfg)
+			var updates []tgbotapi.Update
+			var topics []topicFields
+			if err == nil {
+				if err = json.Unmarshal(resp.Result, &updates); err == nil {
+					err = json.Unmarshal(resp.Result, &topics)
+				}
+			}
+			if err != nil {
+				telegramPollErrorsTotal.Inc()
+				slog.Warn("failed to get updates, retrying in 3 seconds", "err", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(3 * time.Second):
+				}
+				continue
+			}
+			b.lastPoll.Store(time.Now().UnixNano())
+			for i, upd := range updates {
+				if upd.UpdateID < cfg.Offset {
+					continue
+				}
+				cfg.Offset = upd.UpdateID + 1
+				in := incomingUpdate{update: upd}
+				if i < len(topics) && topics[i].Message != nil && topics[i].Message.IsTopicMessage {
+					in.threadID = topics[i].Message.MessageThreadID
+				}
+				select {
+				case ch <- in:
+					updateQueueDepth.Set(float64(len(ch)))
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// sendMessage надсилає текст (з клавіатурою markup, якщо вона не nil) у тему форуму поточного запиту.
+func (b *Bot) sendMessage(chatID int64, text string, markup interface{}) (int, error) {
+	return b.sendReply(chatID, 0, text, markup)
+}
+
+// sendQuestion надсилає питання, на яке бот чекає відповіді. У групах це відповідь автору з ForceReply:
+// Telegram сам відкриває йому відповідь на питання, а лише відповідь продовжує діалог (див. addressedToBot).
+// Кнопок у групах немає — їхнє натискання прийшло б звичайним повідомленням, не відповіддю.
+func (b *Bot) sendQuestion(chatID int64, text string, markup interface{}) error {
+	o := b.origins.get(chatID)
+	if !o.group {
+		_, err := b.sendMessage(chatID, text, markup)
+		return err
+	}
+	_, err := b.sendReply(chatID, o.messageID, text, tgbotapi.ForceReply{ForceReply: true, Selective: true})
+	return err
+}
+
+// sendReply — sendMessage у відповідь на повідомлення replyTo (0 — не відповідь).
+// telegram-bot-api v5.5.1 не знає message_thread_id, тож у темах повідомлення надсилаються напряму.
+func (b *Bot) sendReply(chatID int64, replyTo int, text string, markup interface{}) (_ int, err error) {
+	span := b.traceTelegram(chatID, "sendMessage")
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+	threadID := b.origins.get(chatID).threadID
+	if threadID == 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyToMessageID = replyTo
+		if markup != nil {
+			msg.ReplyMarkup = markup
+		}
+		sent, err := b.api.Send(msg)
+		return sent.MessageID, recordSendError("sendMessage", err)
+	}
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonZero("reply_to_message_id", replyTo)
+	params.AddNonEmpty("text", text)
+	if markup != nil {
+		if err := params.AddInterface("reply_markup", markup); err != nil {
+			return 0, err
+		}
+	}
+	resp, err := b.api.MakeRequest("sendMessage", params)
+	id, err := sentMessageID(resp, err)
+	return id, recordSendError("sendMessage", err)
+}
+
+// sendFile надсилає фото або документ (method — sendPhoto/sendDocument, field — photo/document) у тему поточного запиту.
+func (b *Bot) sendFile(chatID int64, method, field, name string, data []byte, caption string) (err error) {
+	span := b.traceTelegram(chatID, method)
+	span.SetAttributes(tracing.Int("bytes", len(data)))
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+	file := tgbotapi.FileBytes{Name: name, Bytes: data}
+	threadID := b.origins.get(chatID).threadID
+	if threadID == 0 {
+		var c tgbotapi.Chattable
+		if method == "sendPhoto" {
+			photo := tgbotapi.NewPhoto(chatID, file)
+			photo.Caption = caption
+			c = photo
+		} else {
+			doc := tgbotapi.NewDocument(chatID, file)
+			doc.Caption = caption
+			c = doc
+		}
+		_, err := b.api.Send(c)
+		return recordSendError(method, err)
+	}
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", chatID)
+	params.AddNonZero("message_thread_id", threadID)
+	params.AddNonEmpty("caption", caption)
+	resp, err := b.api.UploadFiles(method, params, []tgbotapi.RequestFile{{Name: field, Data: file}})
+	_, err = sentMessageID(resp, err)
+	return recordSendError(method, err)
+}
+
+func sentMessageID(resp *tgbotapi.APIResponse, err error) (int, error) {
+	if err != nil {
+		return 0, err
+	}
+	var msg tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &msg); err != nil {
+		return 0, fmt.Errorf("decode sent message: %w", err)
+	}
+	return msg.MessageID, nil
+}
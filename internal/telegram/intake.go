@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/analysis"
+)
+
+const (
+	// maxClarificationRounds — скільки разів бот перепитує, перш ніж аналізувати те, що є.
+	maxClarificationRounds = 2
+	// maxQuestionsPerRound — скільки питань ставиться за раз, щоб не перевантажувати користувача.
+	maxQuestionsPerRound = 2
+)
+
+// descriptionDraft — опис бага, який бот доповнює відповідями на уточнювальні питання.
+type descriptionDraft struct {
+	parts     []string
+	rounds    int
+	messageID int
+}
+
+func (d *descriptionDraft) text() string {
+	return strings.Join(d.parts, "\n")
+}
+
+// gateDescription додає повідомлення до незавершеного опису чату й оцінює його.
+// Повертає повний текст і true, якщо його вже можна аналізувати; інакше сам ставить уточнювальні питання.
+func (b *Bot) gateDescription(msg *tgbotapi.Message, text string) (string, int, bool) {
+	chatID := msg.Chat.ID
+	dr := b.drafts.take(b.session(chatID))
+	if dr == nil {
+		dr = &descriptionDraft{messageID: msg.MessageID}
+	}
+	dr.parts = append(dr.parts, text)
+	full := dr.text()
+
+	a := analysis.AssessDescription(full)
+	b.logger(chatID).Debug("description quality", "score", a.Score, "lang", a.Language, "readable", a.Readable, "present", a.Present, "missing", a.Missing)
+
+	if a.LowSignal && len(dr.parts) == 1 {
+		// Нічого не запам'ятовуємо: наступне повідомлення почне опис з нуля.
+		_ = b.sendText(chatID, "That doesn't look like a bug description yet. Please describe what went wrong (which screen, what you did, what you expected and what happened) or send a screenshot.")
+		return "", 0, false
+	}
+	if a.Sufficient || dr.rounds >= maxClarificationRounds {
+		return full, dr.messageID, true
+	}
+
+	dr.rounds++
+	b.drafts.put(b.session(chatID), dr)
+	var sb strings.Builder
+	sb.WriteString("A few details will make the test cases much more precise:\n")
+	for _, q := range a.Questions(maxQuestionsPerRound) {
+		sb.WriteString("• " + q + "\n")
+	}
+	sb.WriteString("\nReply with the details, or send /skip to generate test cases from what you've written so far.")
+	_ = b.sendQuestion(chatID, sb.String(), nil)
+	return "", 0, false
+}
+
+// handleSkip аналізує незавершений опис без подальших уточнень.
+func (b *Bot) handleSkip(ctx context.Context, chatID int64) error {
+	dr := b.drafts.take(b.session(chatID))
+	if dr == nil {
+		return b.sendText(chatID, "Nothing to skip — send a bug description or a screenshot.")
+	}
+	return b.analyzeDescription(ctx, chatID, dr.messageID, dr.text())
+}
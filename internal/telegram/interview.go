@@ -0,0 +1,266 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/history"
+)
+
+// maxReportScreenshots — скільки вкладених скріншотів аналізується після інтерв'ю (решта ігнорується).
+const maxReportScreenshots = 3
+
+// interviewStep — поле баг-репорту, про яке бот питає на кроці інтерв'ю.
+type interviewStep int
+
+const (
+	stepOS interviewStep = iota
+	stepDevice
+	stepAppVersion
+	stepSteps
+	stepExpected
+	stepActual
+	stepFrequency
+	stepAttachments
+	stepCount
+)
+
+// interviewQuestion — питання кроку та (опційно) варіанти відповіді для клавіатури.
+type interviewQuestion struct {
+	prompt   string
+	choices  []string
+	required bool
+}
+
+var interviewQuestions = [stepCount]interviewQuestion{
+	stepOS:         {prompt: "Which OS / platform? (e.g. Android 14, iOS 17.5, Windows 11)"},
+	stepDevice:     {prompt: "Which browser or device? (e.g. Chrome 126, Safari, Pixel 7)"},
+	stepAppVersion: {prompt: "Which app version or build? (e.g. 2.14.0 (512), staging build from today)"},
+	stepSteps:      {prompt: "What are the steps to reproduce? One step per line works best.", required: true},
+	stepExpected:   {prompt: "What did you expect to happen?", required: true},
+	stepActual:     {prompt: "What actually happened? (error text, what you saw on the screen)", required: true},
+	stepFrequency:  {prompt: "How often does it happen? (Always, Sometimes, Once)", choices: []string{"Always", "Sometimes", "Once"}},
+	stepAttachments: {
+		prompt:  "Send screenshots if you have any (up to 3 are analyzed), then /done.",
+		choices: []string{"/done"},
+	},
+}
+
+// bugInterview — стан інтерв'ю /report у чаті: поточний крок і зібрані відповіді.
+type bugInterview struct {
+	step        interviewStep
+	answers     [stepCount]string
+	attachments []string // file_id скріншотів
+	messageID   int      // повідомлення /report, з якого почалося інтерв'ю
+}
+
+// environment повертає блок середовища з відповідей.
+func (iv *bugInterview) environment() *analysis.Environment {
+	return &analysis.Environment{
+		OS:         iv.answers[stepOS],
+		Device:     iv.answers[stepDevice],
+		AppVersion: iv.answers[stepAppVersion],
+	}
+}
+
+// report збирає відповіді в текстовий баг-репорт для аналізатора.
+func (iv *bugInterview) report() string {
+	var b strings.Builder
+	b.WriteString("Bug report\n")
+	field := func(name string, step interviewStep) {
+		if v := iv.answers[step]; v != "" {
+			b.WriteString(name + ": " + v + "\n")
+		}
+	}
+	field("OS / platform", stepOS)
+	field("Browser / device", stepDevice)
+	field("App version", stepAppVersion)
+	if v := iv.answers[stepSteps]; v != "" {
+		b.WriteString("Steps to reproduce:\n" + v + "\n")
+	}
+	field("Expected result", stepExpected)
+	field("Actual result", stepActual)
+	field("Frequency", stepFrequency)
+	if n := len(iv.attachments); n > 0 {
+		fmt.Fprintf(&b, "Screenshots attached: %d\n", n)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// handleReport починає (або перезапускає) покрокове інтерв'ю про баг.
+func (b *Bot) handleReport(chatID int64, messageID int) error {
+	b.drafts.take(b.session(chatID))
+	iv := &bugInterview{messageID: messageID}
+	b.interviews.put(b.session(chatID), iv)
+	if err := b.sendText(chatID, "Let's file a bug report step by step. Answer each question; send /skip to leave an optional field empty or /cancel to stop."); err != nil {
+		return err
+	}
+	return b.askInterviewQuestion(chatID, iv)
+}
+
+// handleCancel перериває інтерв'ю /report або скасовує перевірку імпортованого набору тест-кейсів.
+func (b *Bot) handleCancel(chatID int64) error {
+	if ts := b.suites.take(b.session(chatID)); ts != nil {
+		return b.sendText(chatID, "Dropped the imported test cases from "+ts.name+".")
+	}
+	if b.interviews.take(b.session(chatID)) == nil {
+		return b.sendText(chatID, "Nothing to cancel.")
+	}
+	return b.sendTextWithMarkup(chatID, "Bug report cancelled.", tgbotapi.NewRemoveKeyboard(true))
+}
+
+// askInterviewQuestion надсилає питання поточного кроку (з кнопками, якщо є варіанти відповіді).
+func (b *Bot) askInterviewQuestion(chatID int64, iv *bugInterview) error {
+	q := interviewQuestions[iv.step]
+	text := fmt.Sprintf("%d/%d. %s", iv.step+1, stepCount, q.prompt)
+	if len(q.choices) == 0 {
+		return b.sendQuestion(chatID, text, tgbotapi.NewRemoveKeyboard(true))
+	}
+	row := make([]tgbotapi.KeyboardButton, 0, len(q.choices))
+	for _, c := range q.choices {
+		row = append(row, tgbotapi.NewKeyboardButton(c))
+	}
+	kb := tgbotapi.NewOneTimeReplyKeyboard(row)
+	return b.sendQuestion(chatID, text, kb)
+}
+
+// continueInterview приймає відповідь на поточне питання інтерв'ю і переходить до наступного кроку.
+func (b *Bot) continueInterview(ctx context.Context, msg *tgbotapi.Message, iv *bugInterview) error {
+	chatID := msg.Chat.ID
+
+	// Скріншот приймається на будь-якому кроці; питання при цьому не змінюється.
+	if fileID := imageFileID(msg); fileID != "" {
+		iv.attachments = append(iv.attachments, fileID)
+		if iv.step == stepAttachments {
+			return b.sendQuestion(chatID, fmt.Sprintf("Screenshot %d added. Send more or /done.", len(iv.attachments)), nil)
+		}
+		_ = b.sendText(chatID, "Screenshot added to the report.")
+		return b.askInterviewQuestion(chatID, iv)
+	}
+
+	answer := b.scrubber.Scrub(strings.TrimSpace(msg.Text))
+	if answer == "" {
+		return b.askInterviewQuestion(chatID, iv)
+	}
+	if iv.step == stepAttachments {
+		return b.sendQuestion(chatID, "Send a screenshot, or /done to finish the report.", nil)
+	}
+	if answer == "-" {
+		return b.skipInterviewStep(ctx, chatID, iv)
+	}
+	iv.answers[iv.step] = answer
+	return b.advanceInterview(ctx, chatID, iv)
+}
+
+// skipInterviewStep пропускає поточне питання, якщо воно необов'язкове.
+func (b *Bot) skipInterviewStep(ctx context.Context, chatID int64, iv *bugInterview) error {
+	if interviewQuestions[iv.step].required {
+		return b.sendQuestion(chatID, "This one is needed to write test cases — please answer it, or /cancel the report.", nil)
+	}
+	return b.advanceInterview(ctx, chatID, iv)
+}
+
+func (b *Bot) advanceInterview(ctx context.Context, chatID int64, iv *bugInterview) error {
+	iv.step++
+	if iv.step >= stepCount {
+		return b.finishInterview(ctx, chatID)
+	}
+	return b.askInterviewQuestion(chatID, iv)
+}
+
+// finishInterview аналізує зібраний баг-репорт і вкладені скріншоти та надсилає результат з блоком середовища.
+func (b *Bot) finishInterview(ctx context.Context, chatID int64) error {
+	iv := b.interviews.take(b.session(chatID))
+	if iv == nil {
+		return b.sendText(chatID, "No bug report in progress. Send /report to start one.")
+	}
+	for s := stepSteps; s <= stepActual; s++ {
+		if iv.answers[s] == "" {
+			b.interviews.put(b.session(chatID), iv)
+			iv.step = s
+			return b.askInterviewQuestion(chatID, iv)
+		}
+	}
+
+	// Звіт — один текстовий аналіз плюс по аналізу на кожен скріншот; без квоти інтерв'ю лишається, щоб надіслати /done пізніше.
+	images := len(iv.attachments)
+	if images > maxReportScreenshots {
+		images = maxReportScreenshots
+	}
+	// Обидва ліміти перевіряються одним викликом: відмова за скріншоти не повинна списати текстовий аналіз.
+	if !b.allowAnalyses(chatID, map[analysisKind]int{kindText: 1, kindImage: images}) {
+		b.interviews.put(b.session(chatID), iv)
+		return nil
+	}
+
+	report := iv.report()
+	bugQuery := history.BugQuery{Text: report}
+
+	var screenshots [][]byte
+	for _, fileID := range iv.attachments {
+		if len(screenshots) == maxReportScreenshots {
+			break
+		}
+		data, err := b.downloadImage(ctx, chatID, fileID)
+		if data == nil {
+			if err != nil {
+				b.logger(chatID).Warn("report attachment", "err", err)
+			}
+			continue
+		}
+		data, ok := b.redactScreenshot(ctx, chatID, iv.messageID, data)
+		if !ok {
+			continue
+		}
+		screenshots = append(screenshots, data)
+	}
+	if len(screenshots) > 0 {
+		if h, err := analysis.PerceptualHash(screenshots[0]); err == nil {
+			bugQuery.PHash, bugQuery.HasPHash = h, true
+		}
+	}
+	b.notifySimilarBugs(chatID, bugQuery)
+
+	_ = b.sendTextWithMarkup(chatID, "Thanks, the report is complete.", tgbotapi.NewRemoveKeyboard(true))
+	progressMsgID, _ := b.sendTextWithID(chatID, "Analyzing your bug report... (this may take 1–2 min)")
+	res, err := b.analyzer.AnalyzeText(b.analysisContext(ctx, chatID), report)
+	header := ""
+	if err != nil {
+		b.logger(chatID).Warn("analyze report failed, using the report as is", "err", err)
+		res = analysis.FallbackFromUserDescription(report)
+		header = "Test cases based on your report (AI was unavailable; start Ollama for full analysis):\n\n"
+	}
+	for i, data := range screenshots {
+		shot, err := b.analyzer.Analyze(b.analysisContext(ctx, chatID), data)
+		if err != nil {
+			b.logger(chatID).Warn("analyze report screenshot", "screenshot", i+1, "err", err)
+			continue
+		}
+		analysis.MergeAnalyses(res, shot)
+	}
+	if progressMsgID != 0 {
+		_ = b.editMessage(chatID, progressMsgID, "Analysis complete.")
+	}
+
+	// Відповіді тестувальника точніші за здогадки моделі.
+	res.Environment = analysis.MergeEnvironment(iv.environment(), res.Environment)
+	if r := analysis.NormalizeReproducibility(iv.answers[stepFrequency]); r != "" {
+		res.Reproducibility = r
+	}
+	b.sendResult(ctx, chatID, header, res, nil, true)
+	if len(screenshots) == 1 {
+		b.sendAnnotatedScreenshot(chatID, screenshots[0], res)
+	}
+	b.rememberBug(chatID, iv.messageID, bugQuery, res)
+	return nil
+}
+
+// sendTextWithMarkup надсилає повідомлення з клавіатурою (або командою прибрати її).
+func (b *Bot) sendTextWithMarkup(chatID int64, text string, markup interface{}) error {
+	_, err := b.sendMessage(chatID, text, markup)
+	return err
+}
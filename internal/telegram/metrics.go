@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/metrics"
+)
+
+var (
+	updatesTotal = metrics.NewCounter("bugreportbot_updates_total",
+		"Telegram messages received, by type (command, photo, document, text, other).", "type")
+	updateErrorsTotal = metrics.NewCounter("bugreportbot_update_errors_total",
+		"Messages whose handler returned an error.")
+	updateQueueDepth = metrics.NewGauge("bugreportbot_update_queue_depth",
+		"Updates received from Telegram and waiting to be handled.")
+	telegramSendErrorsTotal = metrics.NewCounter("bugreportbot_telegram_send_errors_total",
+		"Failed Telegram API calls that send or edit messages, by method.", "method")
+	telegramPollErrorsTotal = metrics.NewCounter("bugreportbot_telegram_poll_errors_total",
+		"Failed getUpdates calls.")
+	accessDeniedTotal = metrics.NewCounter("bugreportbot_access_denied_total",
+		"Messages rejected by access control.")
+	rateLimitedTotal = metrics.NewCounter("bugreportbot_rate_limited_total",
+		"Analyses rejected by rate limits and quotas, by analysis kind and limit (window, user, chat).", "kind", "scope")
+)
+
+// telegramStaleAfter — скільки може не бути успішного getUpdates (long polling чекає до 60 с), поки зв'язок вважається робочим.
+const telegramStaleAfter = 3 * time.Minute
+
+// updateType — тип повідомлення для метрик.
+func updateType(msg *tgbotapi.Message) string {
+	switch {
+	case msg.IsCommand():
+		return "command"
+	case len(msg.Photo) > 0:
+		return "photo"
+	case msg.Document != nil:
+		return "document"
+	case msg.Text != "":
+		return "text"
+	default:
+		return "other"
+	}
+}
+
+// recordSendError рахує невдалий виклик Telegram API і повертає err без змін.
+func recordSendError(method string, err error) error {
+	if err != nil {
+		telegramSendErrorsTotal.Inc(method)
+	}
+	return err
+}
+
+// CheckTelegram повідомляє, чи бот нещодавно успішно отримував апдейти (для /healthz і /readyz).
+func (b *Bot) CheckTelegram(context.Context) error {
+	last := b.lastPoll.Load()
+	if last == 0 {
+		return fmt.Errorf("no successful getUpdates yet")
+	}
+	if since := time.Since(time.Unix(0, last)); since > telegramStaleAfter {
+		return fmt.Errorf("last successful getUpdates %s ago", since.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/config"
+	"bugreportbot/internal/storage"
+)
+
+// analysisKind — тип аналізу для лімітів: скріншоти дорогі (vision-модель), тексти дешеві.
+type analysisKind string
+
+const (
+	kindImage analysisKind = "image"
+	kindText  analysisKind = "text"
+)
+
+// noun — як назвати аналізи цього типу у відповіді користувачу.
+func (k analysisKind) noun(n int) string {
+	word := "text"
+	if k == kindImage {
+		word = "screenshot"
+	}
+	if n == 1 {
+		return word + " analysis"
+	}
+	return word + " analyses"
+}
+
+// usage — скільки аналізів користувач або чат запустив за день.
+type usage struct {
+	Name   string `json:"name,omitempty"`
+	Images int    `json:"images"`
+	Texts  int    `json:"texts"`
+}
+
+func (u usage) count(kind analysisKind) int {
+	if kind == kindImage {
+		return u.Images
+	}
+	return u.Texts
+}
+
+// quotaDay — використання за поточний день; зберігається у файлі, щоб перезапуск не обнуляв квоти.
+type quotaDay struct {
+	Day   string           `json:"day"`
+	Users map[string]usage `json:"users"`
+	Chats map[string]usage `json:"chats"`
+}
+
+// windowKey — ковзне вікно лімітів одного користувача для одного типу аналізу.
+type windowKey struct {
+	userID int64
+	kind   analysisKind
+}
+
+// analysisKinds — порядок, у якому перевіряються ліміти запиту з кількох типів аналізу.
+var analysisKinds = []analysisKind{kindImage, kindText}
+
+// limitDenial пояснює, чому аналіз відхилено і коли можна спробувати знову.
+type limitDenial struct {
+	kind  analysisKind
+	scope string // "window", "user" або "chat"
+	limit int
+	wait  time.Duration
+}
+
+// rateLimiter рахує аналізи: ліміт за ковзне вікно на користувача і денні квоти на користувача та групу.
+type rateLimiter struct {
+	mu   sync.Mutex
+	path string
+
+	window    time.Duration
+	perWindow map[analysisKind]int
+	userDaily map[analysisKind]int
+	chatDaily map[analysisKind]int
+	recent    map[windowKey][]time.Time
+	day       quotaDay
+	now       func() time.Time
+}
+
+func newRateLimiter(cfg *config.Config, path string) (*rateLimiter, error) {
+	l := &rateLimiter{
+		path:      path,
+		window:    cfg.RateLimitWindow,
+		perWindow: map[analysisKind]int{kindImage: cfg.ImageRateLimit, kindText: cfg.TextRateLimit},
+		userDaily: map[analysisKind]int{kindImage: cfg.ImageDailyQuota, kindText: cfg.TextDailyQuota},
+		chatDaily: map[analysisKind]int{kindImage: cfg.ChatImageDailyQuota, kindText: cfg.ChatTextDailyQuota},
+		recent:    make(map[windowKey][]time.Time),
+		now:       time.Now,
+	}
+	if err := storage.LoadJSON(path, &l.day); err != nil {
+		return nil, fmt.Errorf("load quotas: %w", err)
+	}
+	l.resetDayLocked(l.now())
+	return l, nil
+}
+
+// resetDayLocked починає новий день квот, якщо дата змінилася; l.mu має бути захоплений.
+func (l *rateLimiter) resetDayLocked(now time.Time) {
+	today := now.Format("2006-01-02")
+	if l.day.Day != today {
+		l.day = quotaDay{Day: today}
+	}
+	if l.day.Users == nil {
+		l.day.Users = make(map[string]usage)
+	}
+	if l.day.Chats == nil {
+		l.day.Chats = make(map[string]usage)
+	}
+}
+
+// untilMidnight — скільки лишилося до скидання денних квот.
+func untilMidnight(now time.Time) time.Duration {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+// allow списує аналізи з лімітів користувача userID у чаті chatID: req — скільки аналізів кожного типу потрібно.
+// Усі ліміти перевіряються разом: якщо хоч один вичерпано, нічого не списується і повертається пояснення.
+func (l *rateLimiter) allow(userID, chatID int64, name string, req map[analysisKind]int) *limitDenial {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.resetDayLocked(now)
+
+	userKey := strconv.FormatInt(userID, 10)
+	chatKey := strconv.FormatInt(chatID, 10)
+	group := chatID != userID
+	need := make(map[analysisKind]int, len(req))
+	for _, kind := range analysisKinds {
+		n := req[kind]
+		if n <= 0 {
+			continue
+		}
+		need[kind] = n
+		if limit := l.perWindow[kind]; limit > 0 {
+			// Запит, більший за весь ліміт (інтерв'ю з кількома скріншотами), займає у вікні рівно весь ліміт.
+			if n > limit {
+				need[kind] = limit
+			}
+			recent := l.recentLocked(windowKey{userID: userID, kind: kind}, now)
+			if over := len(recent) + need[kind] - limit; over > 0 {
+				return &limitDenial{kind: kind, scope: "window", limit: limit, wait: recent[over-1].Add(l.window).Sub(now)}
+			}
+		}
+		if limit := l.userDaily[kind]; limit > 0 && l.day.Users[userKey].count(kind)+n > limit {
+			return &limitDenial{kind: kind, scope: "user", limit: limit, wait: untilMidnight(now)}
+		}
+		if limit := l.chatDaily[kind]; group && limit > 0 && l.day.Chats[chatKey].count(kind)+n > limit {
+			return &limitDenial{kind: kind, scope: "chat", limit: limit, wait: untilMidnight(now)}
+		}
+	}
+	if len(need) == 0 {
+		return nil
+	}
+
+	for kind, entries := range need {
+		key := windowKey{userID: userID, kind: kind}
+		for i := 0; i < entries; i++ {
+			l.recent[key] = append(l.recent[key], now)
+		}
+		n := req[kind]
+		l.day.Users[userKey] = addUsage(l.day.Users[userKey], name, kind, n)
+		if group {
+			l.day.Chats[chatKey] = addUsage(l.day.Chats[chatKey], "", kind, n)
+		}
+	}
+	if err := storage.SaveJSON(l.path, l.day); err != nil {
+		slog.Warn("save quotas", "err", err)
+	}
+	return nil
+}
+
+// recentLocked прибирає з вікна key аналізи, старші за l.window, і повертає решту; l.mu має бути захоплений.
+func (l *rateLimiter) recentLocked(key windowKey, now time.Time) []time.Time {
+	recent := l.recent[key]
+	for len(recent) > 0 && now.Sub(recent[0]) >= l.window {
+		recent = recent[1:]
+	}
+	l.recent[key] = recent
+	return recent
+}
+
+func addUsage(u usage, name string, kind analysisKind, n int) usage {
+	if name != "" {
+		u.Name = name
+	}
+	if kind == kindImage {
+		u.Images += n
+	} else {
+		u.Texts += n
+	}
+	return u
+}
+
+// formatWait пише тривалість по-людськи, округлюючи до хвилин угору: "12 minutes", "3 hours 5 minutes".
+func formatWait(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return "1 " + unit
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+	if minutes < 60 {
+		return plural(minutes, "minute")
+	}
+	text := plural(minutes/60, "hour")
+	if minutes%60 != 0 {
+		text += " " + plural(minutes%60, "minute")
+	}
+	return text
+}
+
+// allowAnalysis перевіряє ліміти автора поточного повідомлення перед n аналізами типу kind.
+func (b *Bot) allowAnalysis(chatID int64, kind analysisKind, n int) bool {
+	return b.allowAnalyses(chatID, map[analysisKind]int{kind: n})
+}
+
+// allowAnalyses перевіряє ліміти автора поточного повідомлення одразу для кількох типів аналізу (req: тип → кількість).
+// Адміністратори лімітів не мають. При відмові бот сам відповідає, коли можна спробувати знову.
+func (b *Bot) allowAnalyses(chatID int64, req map[analysisKind]int) bool {
+	o := b.origins.get(chatID)
+	userID := o.userID
+	if userID == 0 {
+		userID = chatID
+	}
+	if b.access.enabled && b.access.role(userID, chatID) == RoleAdmin {
+		return true
+	}
+	d := b.limiter.allow(userID, chatID, o.author, req)
+	if d == nil {
+		return true
+	}
+	rateLimitedTotal.Inc(string(d.kind), d.scope)
+	b.logger(chatID).Info("analysis rate limited", "kind", d.kind, "scope", d.scope, "limit", d.limit, "wait", d.wait.Round(time.Second))
+
+	amount := limitText(d.limit, d.kind)
+	var text string
+	switch d.scope {
+	case "window":
+		text = fmt.Sprintf("You've used your %s for the last %s. Try again in %s.", amount, formatWait(b.limiter.window), formatWait(d.wait))
+	case "chat":
+		text = fmt.Sprintf("This chat has used today's %s. Try again in %s, when the quota resets.", amount, formatWait(d.wait))
+	default:
+		text = fmt.Sprintf("You've used today's %s. Try again in %s, when the quota resets.", amount, formatWait(d.wait))
+	}
+	_ = b.sendText(chatID, "⏳ "+text)
+	return false
+}
+
+// handleQuota показує ліміти і сьогоднішнє використання: /quota або /quota <user ID> (чи відповіддю на повідомлення).
+func (b *Bot) handleQuota(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	l := b.limiter
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Limits per user: %s, %s per %s; %s, %s per day.\n",
+		limitText(l.perWindow[kindImage], kindImage), limitText(l.perWindow[kindText], kindText), formatWait(l.window),
+		limitText(l.userDaily[kindImage], kindImage), limitText(l.userDaily[kindText], kindText)))
+	if l.chatDaily[kindImage] > 0 || l.chatDaily[kindText] > 0 {
+		sb.WriteString(fmt.Sprintf("Limits per group chat: %s, %s per day.\n",
+			limitText(l.chatDaily[kindImage], kindImage), limitText(l.chatDaily[kindText], kindText)))
+	}
+	// /quota доступна лише адміністраторам, тож контроль доступу тут завжди увімкнений.
+	sb.WriteString("Admins have no limits.\n")
+
+	l.mu.Lock()
+	now := l.now()
+	l.resetDayLocked(now)
+	sb.WriteString(fmt.Sprintf("\nToday (%s), resets in %s:\n", l.day.Day, formatWait(untilMidnight(now))))
+	if userID, name, _, err := targetUser(msg); err == nil {
+		u := l.day.Users[strconv.FormatInt(userID, 10)]
+		if name == "" {
+			name = u.Name
+		}
+		window := usage{
+			Images: len(l.recentLocked(windowKey{userID, kindImage}, now)),
+			Texts:  len(l.recentLocked(windowKey{userID, kindText}, now)),
+		}
+		l.mu.Unlock()
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", userLabel(userID, name), usageText(u)))
+		sb.WriteString(fmt.Sprintf("Last %s: %s", formatWait(l.window), usageText(window)))
+		return b.sendText(chatID, sb.String())
+	}
+	users := sortedUsage(l.day.Users)
+	chats := sortedUsage(l.day.Chats)
+	l.mu.Unlock()
+
+	if len(users) == 0 {
+		sb.WriteString("No analyses yet.")
+		return b.sendText(chatID, sb.String())
+	}
+	sb.WriteString("\nUsers:\n")
+	for _, e := range users {
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", userLabel(e.id, e.u.Name), usageText(e.u)))
+	}
+	if len(chats) > 0 {
+		sb.WriteString("\nGroup chats:\n")
+		for _, e := range chats {
+			sb.WriteString(fmt.Sprintf("• %d — %s\n", e.id, usageText(e.u)))
+		}
+	}
+	return b.sendLongText(chatID, sb.String())
+}
+
+func limitText(limit int, kind analysisKind) string {
+	if limit <= 0 {
+		return "unlimited " + kind.noun(2)
+	}
+	return fmt.Sprintf("%d %s", limit, kind.noun(limit))
+}
+
+func usageText(u usage) string {
+	return fmt.Sprintf("%d screenshots, %d texts", u.Images, u.Texts)
+}
+
+type usageEntry struct {
+	id int64
+	u  usage
+}
+
+// sortedUsage повертає використання від найбільшого: спершу за скріншотами, потім за текстами.
+func sortedUsage(m map[string]usage) []usageEntry {
+	entries := make([]usageEntry, 0, len(m))
+	for k, u := range m {
+		id, _ := strconv.ParseInt(k, 10, 64)
+		entries = append(entries, usageEntry{id: id, u: u})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].u.Images != entries[j].u.Images {
+			return entries[i].u.Images > entries[j].u.Images
+		}
+		if entries[i].u.Texts != entries[j].u.Texts {
+			return entries[i].u.Texts > entries[j].u.Texts
+		}
+		return entries[i].id < entries[j].id
+	})
+	return entries
+}
@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bugreportbot/internal/config"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	const user, other, group = 1, 2, -100
+
+	type step struct {
+		advance   time.Duration
+		userID    int64
+		chatID    int64
+		req       map[analysisKind]int
+		wantScope string // "" — дозволено
+	}
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		steps []step
+		// Очікуване використання користувача user наприкінці.
+		wantUsage usage
+	}{
+		{
+			name: "window limit frees up after window",
+			cfg:  config.Config{RateLimitWindow: time.Hour, ImageRateLimit: 2},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}},
+				{advance: 10 * time.Minute, userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}, wantScope: "window"},
+				{advance: 51 * time.Minute, userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}, wantScope: "window"},
+			},
+			wantUsage: usage{Images: 3},
+		},
+		{
+			name: "window is per user and per kind",
+			cfg:  config.Config{RateLimitWindow: time.Hour, ImageRateLimit: 1, TextRateLimit: 1},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 1}},
+				{userID: other, chatID: other, req: map[analysisKind]int{kindImage: 1}},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 1}, wantScope: "window"},
+			},
+			wantUsage: usage{Images: 1, Texts: 1},
+		},
+		{
+			name: "oversize request takes the whole window once",
+			cfg:  config.Config{RateLimitWindow: time.Hour, ImageRateLimit: 2},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 5}},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 1}, wantScope: "window"},
+				// Вікно зайняте рівно двома записами, тож після нього ліміт повністю вільний.
+				{advance: time.Hour, userID: user, chatID: user, req: map[analysisKind]int{kindImage: 2}},
+			},
+			wantUsage: usage{Images: 7},
+		},
+		{
+			name: "user daily quota",
+			cfg:  config.Config{RateLimitWindow: time.Hour, TextDailyQuota: 2},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 2}},
+				{advance: 2 * time.Hour, userID: user, chatID: user, req: map[analysisKind]int{kindText: 1}, wantScope: "user"},
+				{userID: other, chatID: other, req: map[analysisKind]int{kindText: 1}},
+			},
+			wantUsage: usage{Texts: 2},
+		},
+		{
+			name: "chat daily quota counts only group chats",
+			cfg:  config.Config{RateLimitWindow: time.Hour, ChatImageDailyQuota: 2},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindImage: 3}},
+				{userID: user, chatID: group, req: map[analysisKind]int{kindImage: 1}},
+				{userID: other, chatID: group, req: map[analysisKind]int{kindImage: 1}},
+				{userID: user, chatID: group, req: map[analysisKind]int{kindImage: 1}, wantScope: "chat"},
+			},
+			wantUsage: usage{Images: 4},
+		},
+		{
+			name: "combined request is charged all or nothing",
+			cfg:  config.Config{RateLimitWindow: time.Hour, TextRateLimit: 5, ImageDailyQuota: 2},
+			steps: []step{
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 1, kindImage: 3}, wantScope: "user"},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 1, kindImage: 3}, wantScope: "user"},
+				{userID: user, chatID: user, req: map[analysisKind]int{kindText: 1, kindImage: 2}},
+			},
+			wantUsage: usage{Images: 2, Texts: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := newRateLimiter(&tt.cfg, filepath.Join(t.TempDir(), "quotas.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC)
+			l.now = func() time.Time { return now }
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				d := l.allow(s.userID, s.chatID, "", s.req)
+				scope := ""
+				if d != nil {
+					scope = d.scope
+				}
+				if scope != s.wantScope {
+					t.Fatalf("step %d: denial scope = %q, want %q", i, scope, s.wantScope)
+				}
+			}
+			if got := l.day.Users["1"]; got != tt.wantUsage {
+				t.Errorf("usage = %+v, want %+v", got, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestRateLimiterDayReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	cfg := &config.Config{RateLimitWindow: time.Hour, ImageDailyQuota: 1}
+	// Конструктор звіряє збережений день із реальним годинником, тож тест живе в сьогоднішній даті.
+	y, m, day := time.Now().Date()
+	now := time.Date(y, m, day, 23, 30, 0, 0, time.Local)
+
+	l, err := newRateLimiter(cfg, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+	if d := l.allow(1, 1, "", map[analysisKind]int{kindImage: 1}); d != nil {
+		t.Fatalf("first analysis denied: %+v", d)
+	}
+
+	// Квоти зберігаються у файлі: перезапуск у той самий день їх не обнуляє.
+	l, err = newRateLimiter(cfg, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+	d := l.allow(1, 1, "", map[analysisKind]int{kindImage: 1})
+	if d == nil || d.scope != "user" || d.wait != 30*time.Minute {
+		t.Fatalf("after restart: denial = %+v, want user quota with 30m wait", d)
+	}
+
+	now = now.Add(time.Hour)
+	if d := l.allow(1, 1, "", map[analysisKind]int{kindImage: 1}); d != nil {
+		t.Fatalf("next day: denial = %+v", d)
+	}
+}
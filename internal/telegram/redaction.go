@@ -0,0 +1,103 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/storage"
+)
+
+// SetRedactor вмикає розмиття чутливих даних на скріншотах (nil — редагування недоступне).
+func (b *Bot) SetRedactor(r *analysis.Redactor) {
+	b.redactor = r
+}
+
+// redactionEnabled повертає, чи треба редагувати скріншоти в цьому чаті.
+func (b *Bot) redactionEnabled(chatID int64) bool {
+	if v := b.settings.Get(chatID).Redact; v != nil {
+		return *v
+	}
+	return b.redactByDefault
+}
+
+// redactionAuditEntry — запис журналу: що і де замазано (без самих значень).
+type redactionAuditEntry struct {
+	Time       time.Time            `json:"time"`
+	ChatID     int64                `json:"chatId"`
+	MessageID  int                  `json:"messageId"`
+	Redactions []analysis.Redaction `json:"redactions"`
+}
+
+// redactScreenshot розмиває чутливі дані перед аналізом і збереженням. Якщо OCR недоступний,
+// скріншот не аналізується взагалі (ok=false): краще попросити користувача, ніж відправити дані в модель.
+func (b *Bot) redactScreenshot(ctx context.Context, chatID int64, messageID int, data []byte) ([]byte, bool) {
+	if !b.redactionEnabled(chatID) {
+		return data, true
+	}
+	if b.redactor == nil {
+		b.logger(chatID).Warn("screenshot refused: redaction is on but OCR is not configured")
+		_ = b.sendText(chatID, "Screenshot redaction is on, but OCR is not configured on this bot (OCR_ENGINE), so the screenshot cannot be checked for personal data and was not analyzed. Describe the bug in text, or disable redaction with /redact off.")
+		return nil, false
+	}
+	redacted, found, err := b.redactor.Redact(ctx, data)
+	if err != nil {
+		b.logger(chatID).Warn("redact screenshot", "err", err)
+		_ = b.sendText(chatID, "Could not check the screenshot for personal data, so it was not analyzed. Try again, or disable redaction with /redact off.")
+		return nil, false
+	}
+	if len(found) == 0 {
+		return data, true
+	}
+
+	entry := redactionAuditEntry{Time: time.Now(), ChatID: chatID, MessageID: messageID, Redactions: found}
+	if err := storage.AppendJSONLine(b.redactionAuditPath, entry); err != nil {
+		b.logger(chatID).Warn("redaction audit", "err", err)
+	}
+
+	counts := make(map[string]int)
+	var kinds []string
+	for _, r := range found {
+		k := string(r.Kind)
+		if counts[k] == 0 {
+			kinds = append(kinds, k)
+		}
+		counts[k]++
+	}
+	parts := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		parts = append(parts, fmt.Sprintf("%d× %s", counts[k], k))
+	}
+	_ = b.sendText(chatID, "🔒 Blurred personal data on the screenshot before analysis: "+strings.Join(parts, ", ")+".")
+	return redacted, true
+}
+
+// handleRedact показує або перемикає редагування скріншотів для чату.
+func (b *Bot) handleRedact(chatID int64, args string) error {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	switch arg {
+	case "":
+		state := "off"
+		if b.redactionEnabled(chatID) {
+			state = "on"
+		}
+		msg := "Screenshot redaction is " + state + " for this chat. Use /redact on or /redact off."
+		if b.redactor == nil {
+			msg += "\n\nNote: redaction needs OCR, which is not configured on this bot (OCR_ENGINE)."
+		}
+		return b.sendText(chatID, msg)
+	case "on", "off":
+		on := arg == "on"
+		if err := b.settings.Update(chatID, func(s *ChatSettings) { s.Redact = &on }); err != nil {
+			return err
+		}
+		if on && b.redactor == nil {
+			return b.sendText(chatID, "Redaction enabled, but OCR is not configured on this bot (OCR_ENGINE), so screenshots cannot be checked yet.")
+		}
+		return b.sendText(chatID, "Screenshot redaction is now "+arg+".")
+	default:
+		return b.sendText(chatID, "Usage: /redact on | off")
+	}
+}
@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/testimport"
+)
+
+// maxSuiteFileSize — найбільший CSV/Markdown-файл з тест-кейсами, який бот приймає.
+const maxSuiteFileSize = 1 << 20
+
+// testSuite — імпортовані тест-кейси команди, що чекають на звіт про баг для перевірки.
+type testSuite struct {
+	name  string
+	cases []analysis.TestCase
+}
+
+// isTestSuiteDocument — CSV або Markdown з тест-кейсами.
+func isTestSuiteDocument(doc *tgbotapi.Document) bool {
+	if doc == nil {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(doc.FileName)) {
+	case ".csv", ".tsv", ".md", ".markdown":
+		return true
+	}
+	return doc.MimeType == "text/csv" || doc.MimeType == "text/markdown"
+}
+
+// handleSuiteDocument імпортує тест-кейси з файла і чекає звіт про баг або скріншот, щодо якого їх перевірити.
+// Підпис до файла одразу використовується як звіт.
+func (b *Bot) handleSuiteDocument(ctx context.Context, msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	doc := msg.Document
+	tooLarge := fmt.Sprintf("The file is too large (max %d KB).", maxSuiteFileSize>>10)
+	if b.fileTooLarge(chatID, int64(doc.FileSize), maxSuiteFileSize) {
+		return b.sendText(chatID, tooLarge)
+	}
+	data, err := b.downloadFile(ctx, chatID, doc.FileID, maxSuiteFileSize)
+	if errors.Is(err, errFileTooLarge) {
+		return b.sendText(chatID, tooLarge)
+	}
+	if err != nil {
+		return b.sendText(chatID, "Could not download the file. Please try again.")
+	}
+	cases, err := testimport.Parse(doc.FileName, data)
+	if err != nil {
+		if errors.Is(err, testimport.ErrNoTestCases) {
+			return b.sendText(chatID, "No test cases found in "+doc.FileName+". Use a CSV with a header row (ID, Title, Preconditions, Steps, Expected, Priority, ...) or Markdown tables like TESTCASES.md.")
+		}
+		return b.sendText(chatID, "Could not read the test cases: "+err.Error())
+	}
+
+	ts := &testSuite{name: doc.FileName, cases: cases}
+	if caption := b.scrubber.Scrub(strings.TrimSpace(msg.Caption)); caption != "" {
+		return b.reviewSuite(ctx, chatID, ts, caption, nil)
+	}
+	b.drafts.take(b.session(chatID))
+	b.suites.put(b.session(chatID), ts)
+	return b.sendQuestion(chatID, fmt.Sprintf("Imported %d test cases from %s.\n\n"+
+		"Now send the bug report (text) or a screenshot to review them against, or /review to look for gaps in the suite itself. /cancel drops the suite.", len(cases), doc.FileName), nil)
+}
+
+// continueSuiteReview використовує наступне повідомлення після імпорту набору як звіт про баг або скріншот.
+func (b *Bot) continueSuiteReview(ctx context.Context, msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	if fileID := imageFileID(msg); fileID != "" {
+		data, err := b.downloadImage(ctx, chatID, fileID)
+		if data == nil {
+			return err
+		}
+		data, ok := b.redactScreenshot(ctx, chatID, msg.MessageID, data)
+		if !ok {
+			return nil
+		}
+		return b.reviewSuite(ctx, chatID, b.suites.take(b.session(chatID)), b.scrubber.Scrub(strings.TrimSpace(msg.Caption)), data)
+	}
+	report := b.scrubber.Scrub(strings.TrimSpace(msg.Text))
+	if report == "" {
+		return b.sendQuestion(chatID, "Send the bug report as text or a screenshot, /review to review the suite on its own, or /cancel.", nil)
+	}
+	return b.reviewSuite(ctx, chatID, b.suites.take(b.session(chatID)), report, nil)
+}
+
+// handleReview перевіряє імпортований набір без звіту (або з текстом після команди як звітом).
+func (b *Bot) handleReview(ctx context.Context, chatID int64, args string) error {
+	ts := b.suites.take(b.session(chatID))
+	if ts == nil {
+		return b.sendText(chatID, "Send a CSV or Markdown file with your test cases first, then /review.")
+	}
+	return b.reviewSuite(ctx, chatID, ts, b.scrubber.Scrub(strings.TrimSpace(args)), nil)
+}
+
+// reviewSuite надсилає огляд набору (слабкі кейси) і окремим результатом — запропоновані нові кейси.
+// Якщо аналізатор не вміє перевіряти набори або недоступний, лишається перевірка лінтером.
+func (b *Bot) reviewSuite(ctx context.Context, chatID int64, ts *testSuite, report string, image []byte) error {
+	if ts == nil {
+		return nil
+	}
+	kind := kindText
+	if image != nil {
+		kind = kindImage
+	}
+	if !b.allowAnalysis(chatID, kind, 1) {
+		// Набір лишається: його можна перевірити, коли квота відновиться.
+		b.suites.put(b.session(chatID), ts)
+		return nil
+	}
+	progressMsgID, _ := b.sendTextWithID(chatID, fmt.Sprintf("Reviewing %d test cases...", len(ts.cases)))
+	var review *analysis.CoverageReview
+	var err error
+	if r, ok := b.analyzer.(analysis.Reviewer); ok {
+		review, err = r.Review(b.analysisContext(ctx, chatID), ts.cases, report, image)
+	}
+	if progressMsgID != 0 {
+		_ = b.editMessage(chatID, progressMsgID, "Review complete.")
+	}
+	if review == nil {
+		if err != nil {
+			b.logger(chatID).Warn("review failed, using linter results", "err", err)
+		}
+		review = analysis.LintReview(ts.cases)
+		review.Notes = append(review.Notes, "AI review was unavailable; only the linter checks are shown.")
+	}
+	review.MapText(b.scrubber.Scrub)
+
+	if err := b.sendLongText(chatID, analysis.FormatCoverageReview(review, len(ts.cases))); err != nil {
+		return err
+	}
+	if len(review.Missing) > 0 {
+		res := &analysis.BugAnalysis{
+			BugTitle:  "Proposed test cases missing from " + ts.name,
+			TestCases: review.Missing,
+		}
+		b.sendResult(ctx, chatID, "", res, nil, true)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionStore зберігає діалоговий стан (чернетки, інтерв'ю, імпортовані набори) по сесіях.
+// Стан, якого не торкались довше за ttl, вважається покинутим і зникає: наступне повідомлення почне все з нуля.
+type sessionStore[T any] struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	bySession map[sessionKey]sessionEntry[T]
+}
+
+type sessionEntry[T any] struct {
+	value   *T
+	touched time.Time
+}
+
+func newSessionStore[T any](ttl time.Duration) *sessionStore[T] {
+	return &sessionStore[T]{ttl: ttl, bySession: make(map[sessionKey]sessionEntry[T])}
+}
+
+// get повертає стан сесії і продовжує йому життя; nil — стану немає або він застарів.
+func (s *sessionStore[T]) get(key sessionKey) *T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.bySession[key]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if s.expired(e, now) {
+		delete(s.bySession, key)
+		return nil
+	}
+	e.touched = now
+	s.bySession[key] = e
+	return e.value
+}
+
+func (s *sessionStore[T]) put(key sessionKey, v *T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// Покинуті сесії інших користувачів прибираються тут, щоб мапа не росла.
+	for k, e := range s.bySession {
+		if s.expired(e, now) {
+			delete(s.bySession, k)
+		}
+	}
+	s.bySession[key] = sessionEntry[T]{value: v, touched: now}
+}
+
+// take забирає стан сесії; застарілий стан не повертається.
+func (s *sessionStore[T]) take(key sessionKey) *T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.bySession[key]
+	delete(s.bySession, key)
+	if !ok || s.expired(e, time.Now()) {
+		return nil
+	}
+	return e.value
+}
+
+func (s *sessionStore[T]) expired(e sessionEntry[T], now time.Time) bool {
+	return s.ttl > 0 && now.Sub(e.touched) > s.ttl
+}
@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionStoreExpires(t *testing.T) {
+	s := newSessionStore[testSuite](time.Minute)
+	alice, bob := sessionKey{chatID: -1, userID: 1}, sessionKey{chatID: -1, userID: 2}
+
+	s.put(alice, &testSuite{name: "a.csv"})
+	if ts := s.get(alice); ts == nil || ts.name != "a.csv" {
+		t.Fatalf("get = %v, want a.csv", ts)
+	}
+	if s.get(bob) != nil {
+		t.Fatal("sessions of different members must not mix")
+	}
+
+	// Стан, якого не торкались довше за ttl, зникає і не повертається.
+	s.bySession[alice] = sessionEntry[testSuite]{value: &testSuite{name: "a.csv"}, touched: time.Now().Add(-2 * time.Minute)}
+	if s.get(alice) != nil {
+		t.Fatal("expired session returned by get")
+	}
+	if _, ok := s.bySession[alice]; ok {
+		t.Fatal("expired session not deleted")
+	}
+
+	s.bySession[alice] = sessionEntry[testSuite]{value: &testSuite{}, touched: time.Now().Add(-2 * time.Minute)}
+	if s.take(alice) != nil {
+		t.Fatal("expired session returned by take")
+	}
+
+	// put прибирає покинуті сесії інших учасників.
+	s.bySession[alice] = sessionEntry[testSuite]{value: &testSuite{}, touched: time.Now().Add(-2 * time.Minute)}
+	s.put(bob, &testSuite{name: "b.md"})
+	if len(s.bySession) != 1 {
+		t.Fatalf("got %d sessions after put, want 1", len(s.bySession))
+	}
+	if ts := s.take(bob); ts == nil || ts.name != "b.md" || s.get(bob) != nil {
+		t.Fatal("take must return the state once")
+	}
+}
+
+func TestSessionStoreGetExtendsLife(t *testing.T) {
+	s := newSessionStore[descriptionDraft](time.Minute)
+	key := sessionKey{chatID: 1, userID: 1}
+	s.bySession[key] = sessionEntry[descriptionDraft]{value: &descriptionDraft{}, touched: time.Now().Add(-50 * time.Second)}
+	if s.get(key) == nil {
+		t.Fatal("fresh session not returned")
+	}
+	if age := time.Since(s.bySession[key].touched); age > time.Second {
+		t.Fatalf("get did not refresh the session, age %s", age)
+	}
+}
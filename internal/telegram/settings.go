@@ -0,0 +1,60 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"bugreportbot/internal/analysis"
+	"bugreportbot/internal/storage"
+)
+
+// ChatSettings — налаштування, які користувач задає командами для конкретного чату.
+type ChatSettings struct {
+	// Project — назва проєкту; чати з однаковим проєктом мають спільну нумерацію тест-кейсів.
+	Project string `json:"project,omitempty"`
+	// IDPrefix — префікс проєкту в ID тест-кейсів (LOGIN → LOGIN-TC-001).
+	IDPrefix string `json:"idPrefix,omitempty"`
+	// Redact — розмивати чутливі дані на скріншотах (nil — значення за замовчуванням з конфігу).
+	Redact *bool `json:"redact,omitempty"`
+	// Mode — режим генерації тест-кейсів (/mode); "" — лише відтворення бага.
+	Mode analysis.GenerationMode `json:"mode,omitempty"`
+	// Format — формат відповіді (/format): "" — звичайний текст, "gherkin" — BDD-сценарії.
+	Format string `json:"format,omitempty"`
+}
+
+// settingsStore зберігає ChatSettings усіх чатів у JSON-файлі.
+type settingsStore struct {
+	mu    sync.Mutex
+	path  string
+	chats map[string]ChatSettings
+}
+
+func newSettingsStore(path string) (*settingsStore, error) {
+	s := &settingsStore{
+		path:  path,
+		chats: make(map[string]ChatSettings),
+	}
+	if err := storage.LoadJSON(path, &s.chats); err != nil {
+		return nil, fmt.Errorf("load chat settings: %w", err)
+	}
+	return s, nil
+}
+
+// Get повертає налаштування чату (нульове значення, якщо чат ще нічого не налаштовував).
+func (s *settingsStore) Get(chatID int64) ChatSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.chats[strconv.FormatInt(chatID, 10)]
+}
+
+// Update змінює налаштування чату через fn і одразу зберігає їх на диск.
+func (s *settingsStore) Update(chatID int64, fn func(*ChatSettings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strconv.FormatInt(chatID, 10)
+	cs := s.chats[key]
+	fn(&cs)
+	s.chats[key] = cs
+	return storage.SaveJSON(s.path, s.chats)
+}
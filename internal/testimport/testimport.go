@@ -0,0 +1,365 @@
+// Package testimport читає наявні тест-кейси команди з CSV і Markdown (таблиці на кшталт TESTCASES.md)
+// у []analysis.TestCase, щоб бот міг їх доповнити або перевірити.
+package testimport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"bugreportbot/internal/analysis"
+)
+
+// ErrNoTestCases — у файлі не знайдено жодного тест-кейсу.
+var ErrNoTestCases = errors.New("no test cases found")
+
+// Parse вибирає формат за розширенням імені файла (.csv, .md, .markdown), а якщо воно невідоме — за вмістом.
+func Parse(name string, data []byte) ([]analysis.TestCase, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv", ".tsv":
+		return ParseCSV(data)
+	case ".md", ".markdown":
+		return ParseMarkdown(string(data))
+	}
+	if LooksLikeMarkdown(string(data)) {
+		return ParseMarkdown(string(data))
+	}
+	return ParseCSV(data)
+}
+
+// LooksLikeMarkdown повідомляє, що текст містить Markdown-таблицю (рядок-роздільник |---|---|).
+func LooksLikeMarkdown(text string) bool {
+	for _, l := range strings.Split(text, "\n") {
+		if isSeparatorRow(splitRow(l)) {
+			return true
+		}
+	}
+	return false
+}
+
+// field — поле TestCase, якому відповідає колонка таблиці.
+type field int
+
+const (
+	fieldUnknown field = iota
+	fieldID
+	fieldTitle
+	fieldPreconditions
+	fieldSteps
+	fieldExpected
+	fieldActual
+	fieldPriority
+	fieldSeverity
+	fieldCategory
+)
+
+// fieldNames — назви колонок (у нижньому регістрі, без розмітки), які розуміє імпорт.
+var fieldNames = map[string]field{
+	"id": fieldID, "tc id": fieldID, "test case id": fieldID, "case id": fieldID, "key": fieldID, "#": fieldID,
+	"title": fieldTitle, "name": fieldTitle, "summary": fieldTitle, "test case": fieldTitle, "scenario": fieldTitle,
+	"preconditions": fieldPreconditions, "precondition": fieldPreconditions, "pre-conditions": fieldPreconditions, "prerequisites": fieldPreconditions, "given": fieldPreconditions,
+	"steps": fieldSteps, "step": fieldSteps, "test steps": fieldSteps, "steps to reproduce": fieldSteps, "actions": fieldSteps,
+	"expected": fieldExpected, "expected result": fieldExpected, "expected results": fieldExpected, "expected behaviour": fieldExpected, "expected behavior": fieldExpected,
+	"actual": fieldActual, "actual result": fieldActual, "actual results": fieldActual,
+	"priority": fieldPriority,
+	"severity": fieldSeverity,
+	"category": fieldCategory, "type": fieldCategory,
+}
+
+func fieldFor(header string) field {
+	return fieldNames[strings.ToLower(strings.Join(strings.Fields(stripMarkup(header)), " "))]
+}
+
+// set записує значення колонки у тест-кейс; порожні значення не затирають уже заповнені поля.
+func set(tc *analysis.TestCase, f field, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "—" || value == "-" {
+		return
+	}
+	switch f {
+	case fieldID:
+		tc.ID = stripMarkup(value)
+	case fieldTitle:
+		tc.Title = stripMarkup(value)
+	case fieldPreconditions:
+		tc.Preconditions = splitPreconditions(value)
+	case fieldSteps:
+		tc.Steps = splitSteps(value)
+	case fieldExpected:
+		tc.Expected = stripMarkup(value)
+	case fieldActual:
+		tc.Actual = stripMarkup(value)
+	case fieldPriority:
+		if p, ok := analysis.ParsePriority(stripMarkup(value)); ok {
+			tc.Priority = p
+		}
+	case fieldSeverity:
+		if s, ok := analysis.ParseSeverity(stripMarkup(value)); ok {
+			tc.Severity = s
+		}
+	case fieldCategory:
+		tc.Category = analysis.ParseCategory(stripMarkup(value))
+	}
+}
+
+// ParseCSV читає CSV з рядком заголовків; роздільник (кома, крапка з комою або табуляція) визначається за заголовком.
+func ParseCSV(data []byte) ([]analysis.TestCase, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = detectDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoTestCases
+	}
+	cols, err := headerFields(rows[0])
+	if err != nil {
+		return nil, err
+	}
+	var out []analysis.TestCase
+	for _, row := range rows[1:] {
+		if tc, ok := rowToTestCase(cols, row); ok {
+			out = append(out, tc)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrNoTestCases
+	}
+	return out, nil
+}
+
+func detectDelimiter(data []byte) rune {
+	first := string(data)
+	if i := strings.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	best, bestCount := ',', strings.Count(first, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+// headerFields зіставляє заголовки з полями; без колонки з назвою або кроками таблиця не схожа на тест-кейси.
+func headerFields(header []string) ([]field, error) {
+	cols := make([]field, len(header))
+	known := false
+	for i, h := range header {
+		cols[i] = fieldFor(h)
+		if cols[i] == fieldTitle || cols[i] == fieldSteps {
+			known = true
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("header %q has no Title or Steps column", strings.Join(header, ", "))
+	}
+	return cols, nil
+}
+
+func rowToTestCase(cols []field, row []string) (analysis.TestCase, bool) {
+	var tc analysis.TestCase
+	for i, v := range row {
+		if i < len(cols) {
+			set(&tc, cols[i], v)
+		}
+	}
+	return tc, tc.Title != "" || len(tc.Steps) > 0
+}
+
+// detailHeadingRe — заголовок детального опису кейсу: "### TC-01: /start returns welcome message".
+var detailHeadingRe = regexp.MustCompile(`^#{2,6}\s+([A-Za-z0-9]+(?:-[A-Za-z0-9]+)*-\d+)\s*[:.—–-]\s*(.+)$`)
+
+// ParseMarkdown читає Markdown-таблиці двох видів, як у TESTCASES.md: зведену (рядок — кейс, колонки — поля)
+// і детальну (| Field | Value |) під заголовком "### TC-01: назва". Дані детальної таблиці доповнюють рядок зведеної з тим самим ID.
+func ParseMarkdown(text string) ([]analysis.TestCase, error) {
+	var (
+		out   []analysis.TestCase
+		byID  = make(map[string]int)
+		lines = strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+		// current — кейс під останнім заголовком "### TC-..", до якого належить таблиця Field | Value.
+		current = -1
+	)
+	upsert := func(tc analysis.TestCase) int {
+		if tc.ID != "" {
+			if i, ok := byID[strings.ToUpper(tc.ID)]; ok {
+				merge(&out[i], tc)
+				return i
+			}
+			byID[strings.ToUpper(tc.ID)] = len(out)
+		}
+		out = append(out, tc)
+		return len(out) - 1
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if m := detailHeadingRe.FindStringSubmatch(line); m != nil {
+			current = upsert(analysis.TestCase{ID: m[1], Title: stripMarkup(m[2])})
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			current = -1
+			continue
+		}
+		header := splitRow(line)
+		if header == nil || i+1 >= len(lines) || !isSeparatorRow(splitRow(lines[i+1])) {
+			continue
+		}
+
+		// Таблиця: заголовок, роздільник і рядки до першого рядка без "|".
+		var rows [][]string
+		for i += 2; i < len(lines); i++ {
+			row := splitRow(lines[i])
+			if row == nil {
+				break
+			}
+			rows = append(rows, row)
+		}
+		i-- // рядок після таблиці (наприклад, заголовок наступного кейсу) розбирає наступна ітерація.
+
+		if isFieldValueTable(header) {
+			var tc analysis.TestCase
+			for _, row := range rows {
+				if len(row) >= 2 {
+					set(&tc, fieldFor(row[0]), strings.Join(row[1:], " | "))
+				}
+			}
+			if current >= 0 {
+				merge(&out[current], tc)
+			} else if tc.Title != "" || len(tc.Steps) > 0 {
+				upsert(tc)
+			}
+			continue
+		}
+		cols, err := headerFields(header)
+		if err != nil {
+			continue
+		}
+		for _, row := range rows {
+			if tc, ok := rowToTestCase(cols, row); ok {
+				upsert(tc)
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, ErrNoTestCases
+	}
+	return out, nil
+}
+
+// isFieldValueTable — таблиця "| Field | Value |" з детальним описом одного кейсу.
+func isFieldValueTable(header []string) bool {
+	return len(header) == 2 && fieldFor(header[0]) == fieldUnknown &&
+		strings.EqualFold(stripMarkup(header[0]), "field")
+}
+
+// merge доповнює dst непорожніми полями src.
+func merge(dst *analysis.TestCase, src analysis.TestCase) {
+	if src.ID != "" {
+		dst.ID = src.ID
+	}
+	if src.Title != "" {
+		dst.Title = src.Title
+	}
+	if len(src.Preconditions) > 0 {
+		dst.Preconditions = src.Preconditions
+	}
+	if len(src.Steps) > 0 {
+		dst.Steps = src.Steps
+	}
+	if src.Expected != "" {
+		dst.Expected = src.Expected
+	}
+	if src.Actual != "" {
+		dst.Actual = src.Actual
+	}
+	if src.Priority != "" {
+		dst.Priority = src.Priority
+	}
+	if src.Severity != "" {
+		dst.Severity = src.Severity
+	}
+	if src.Category != "" {
+		dst.Category = src.Category
+	}
+}
+
+// splitRow розбиває рядок Markdown-таблиці на комірки; nil — рядок не є рядком таблиці.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "|") {
+		return nil
+	}
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	// "\|" — екранована вертикальна риска всередині комірки.
+	line = strings.ReplaceAll(line, `\|`, "\x00")
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(strings.ReplaceAll(c, "\x00", "|"))
+	}
+	return cells
+}
+
+var separatorCellRe = regexp.MustCompile(`^:?-{3,}:?$`)
+
+func isSeparatorRow(cells []string) bool {
+	if len(cells) == 0 {
+		return false
+	}
+	for _, c := range cells {
+		if !separatorCellRe.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+var markupReplacer = strings.NewReplacer("**", "", "__", "", "`", "")
+
+// stripMarkup прибирає жирний шрифт і code-розмітку Markdown.
+func stripMarkup(s string) string {
+	return strings.TrimSpace(markupReplacer.Replace(s))
+}
+
+// lineBreakRe — переноси рядків усередині комірки: справжні та <br> з таблиць.
+var lineBreakRe = regexp.MustCompile(`(?i)\s*(?:<br\s*/?>|\n)\s*`)
+
+// stepNumberRe — нумерація кроків в одному рядку: "1. Open chat. 2. Send /start."
+var stepNumberRe = regexp.MustCompile(`(?:^|\s)\d{1,2}[.)]\s+`)
+
+// splitSteps ділить комірку з кроками за переносами рядків і нумерацією.
+func splitSteps(value string) []string {
+	var out []string
+	for _, line := range lineBreakRe.Split(value, -1) {
+		for _, step := range stepNumberRe.Split(line, -1) {
+			if step = stripMarkup(strings.TrimLeft(step, "-*• ")); step != "" {
+				out = append(out, step)
+			}
+		}
+	}
+	return out
+}
+
+// splitPreconditions ділить комірку з передумовами за переносами рядків і крапкою з комою.
+func splitPreconditions(value string) []string {
+	var out []string
+	for _, line := range lineBreakRe.Split(value, -1) {
+		for _, p := range strings.Split(line, ";") {
+			if p = stripMarkup(strings.TrimLeft(p, "-*• ")); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
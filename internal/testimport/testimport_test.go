@@ -0,0 +1,112 @@
+package testimport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"bugreportbot/internal/analysis"
+)
+
+func TestParseTestcasesMarkdown(t *testing.T) {
+	data, err := os.ReadFile("../../TESTCASES.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases, err := Parse("TESTCASES.md", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 11 {
+		t.Fatalf("got %d test cases, want 11", len(cases))
+	}
+	for i, tc := range cases {
+		if want := fmt.Sprintf("TC-%02d", i+1); tc.ID != want {
+			t.Errorf("case %d ID = %q, want %q", i, tc.ID, want)
+		}
+		if tc.Title == "" || len(tc.Steps) == 0 || tc.Expected == "" || tc.Priority == "" {
+			t.Errorf("%s is incomplete: %+v", tc.ID, tc)
+		}
+	}
+
+	// Зведена таблиця і детальна під "### TC-01: ..." зливаються в один кейс; назва береться з детальної.
+	want := analysis.TestCase{
+		ID:            "TC-01",
+		Title:         "/start returns welcome message",
+		Preconditions: []string{"Bot is running", "user has Telegram."},
+		Steps:         []string{"Open chat with the bot.", "Send /start."},
+		Expected:      "Bot replies with welcome text (Hi, photo/text usage, etc.).",
+		Actual:        "Bot replied with welcome text.",
+		Priority:      analysis.PriorityHigh,
+	}
+	if !reflect.DeepEqual(cases[0], want) {
+		t.Errorf("TC-01\n got: %+v\nwant: %+v", cases[0], want)
+	}
+	if cases[6].Priority != analysis.PriorityLow {
+		t.Errorf("TC-07 priority = %q, want Low", cases[6].Priority)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []analysis.TestCase
+	}{
+		{
+			name: "comma",
+			in:   "ID,Title,Steps,Expected Result,Priority,Severity,Category\nTC-1,Login,\"1. Open app 2. Tap Login\",Dashboard opens,high,major,regression\n,,,,,,\n",
+			want: []analysis.TestCase{{
+				ID: "TC-1", Title: "Login", Steps: []string{"Open app", "Tap Login"}, Expected: "Dashboard opens",
+				Priority: analysis.PriorityHigh, Severity: analysis.SeverityMajor, Category: analysis.CategoryRegression,
+			}},
+		},
+		{
+			name: "semicolon with BOM",
+			in:   "\xef\xbb\xbfName;Preconditions;Steps\nLogout;Signed in, Online;Tap Logout\n",
+			want: []analysis.TestCase{{Title: "Logout", Preconditions: []string{"Signed in, Online"}, Steps: []string{"Tap Logout"}}},
+		},
+		{
+			name: "tab",
+			in:   "Summary\tSteps\tActual\nCrash\tOpen app\tIt crashes\n",
+			want: []analysis.TestCase{{Title: "Crash", Steps: []string{"Open app"}, Actual: "It crashes"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse("cases.csv", []byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := ParseCSV([]byte("Foo,Bar\n1,2\n")); err == nil {
+		t.Error("want error for CSV without Title or Steps column")
+	}
+	if _, err := ParseCSV([]byte("Title,Steps\n,\n")); !errors.Is(err, ErrNoTestCases) {
+		t.Errorf("empty rows: err = %v, want ErrNoTestCases", err)
+	}
+	if _, err := ParseMarkdown("# Notes\n\nNo tables here."); !errors.Is(err, ErrNoTestCases) {
+		t.Errorf("markdown without tables: err = %v, want ErrNoTestCases", err)
+	}
+}
+
+func TestParseDetectsFormat(t *testing.T) {
+	md := "| Title | Steps |\n|---|---|\n| Login | Open app<br>Tap Login |\n"
+	got, err := Parse("cases.txt", []byte(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []analysis.TestCase{{Title: "Login", Steps: []string{"Open app", "Tap Login"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\n got: %+v\nwant: %+v", got, want)
+	}
+}
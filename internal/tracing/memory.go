@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryExporter зберігає експортовані спани в пам'яті — для тестів і налагодження.
+// Після Tracer.ForceFlush у Spans() є всі завершені спани.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter створює порожній InMemoryExporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// Export додає спани до збережених.
+func (e *InMemoryExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Spans повертає копію збережених спанів у порядку завершення.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset видаляє збережені спани.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
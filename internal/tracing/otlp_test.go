@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOTLPExportShape(t *testing.T) {
+	var (
+		gotPath, gotAuth, gotType string
+		body                      []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth, gotType = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	start := time.Unix(1700000000, 5)
+	parent := SpanData{
+		Name:        "telegram.update",
+		SpanContext: SpanContext{TraceID: [16]byte{1, 2, 3}, SpanID: [8]byte{4, 5, 6}},
+		Kind:        KindInternal,
+		Start:       start,
+		End:         start.Add(time.Second),
+		Attrs:       []Attr{String("type", "photo"), Int("update_id", 7), Bool("group", true), {Key: "ratio", Value: 0.5}},
+	}
+	child := SpanData{
+		Name:          "ollama.generate",
+		SpanContext:   SpanContext{TraceID: parent.SpanContext.TraceID, SpanID: [8]byte{9}},
+		ParentSpanID:  parent.SpanContext.SpanID,
+		Kind:          KindClient,
+		Start:         start,
+		End:           start.Add(time.Millisecond),
+		Error:         true,
+		StatusMessage: "timeout",
+	}
+
+	exp := NewOTLPExporter(srv.URL+"/", "bugreportbot-test", map[string]string{"Authorization": "Bearer x"})
+	if err := exp.Export(context.Background(), []SpanData{parent, child}); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/v1/traces" || gotAuth != "Bearer x" || gotType != "application/json" {
+		t.Errorf("path %q, auth %q, content type %q", gotPath, gotAuth, gotType)
+	}
+
+	var req struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value map[string]any
+				}
+			}
+			ScopeSpans []struct {
+				Scope struct{ Name string }
+				Spans []map[string]any
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, body)
+	}
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected structure: %s", body)
+	}
+	res := req.ResourceSpans[0].Resource.Attributes
+	if len(res) != 1 || res[0].Key != "service.name" || res[0].Value["stringValue"] != "bugreportbot-test" {
+		t.Errorf("resource attributes = %+v", res)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans", len(spans))
+	}
+
+	p, c := spans[0], spans[1]
+	checks := []struct {
+		name      string
+		got, want any
+	}{
+		{"traceId", p["traceId"], "01020300000000000000000000000000"},
+		{"spanId", p["spanId"], "0405060000000000"},
+		{"root parentSpanId", p["parentSpanId"], nil},
+		{"kind", p["kind"], float64(1)},
+		{"start", p["startTimeUnixNano"], "1700000000000000005"},
+		{"end", p["endTimeUnixNano"], "1700000001000000005"},
+		{"root status", p["status"], map[string]any{"code": float64(0)}},
+		{"child parentSpanId", c["parentSpanId"], "0405060000000000"},
+		{"child kind", c["kind"], float64(3)},
+		{"child status", c["status"], map[string]any{"code": float64(2), "message": "timeout"}},
+	}
+	for _, ch := range checks {
+		if !jsonEqual(ch.got, ch.want) {
+			t.Errorf("%s = %v, want %v", ch.name, ch.got, ch.want)
+		}
+	}
+	wantAttrs := `[{"key":"type","value":{"stringValue":"photo"}},{"key":"update_id","value":{"intValue":"7"}},{"key":"group","value":{"boolValue":true}},{"key":"ratio","value":{"doubleValue":0.5}}]`
+	if got, _ := json.Marshal(p["attributes"]); string(got) != wantAttrs {
+		t.Errorf("attributes = %s\nwant %s", got, wantAttrs)
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func TestOTLPExportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	err := NewOTLPExporter(srv.URL+"/v1/traces", "svc", nil).Export(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "bad token") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := ParseHeaders(" authorization = Bearer abc , x-scope=team=qa,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["authorization"] != "Bearer abc" || got["x-scope"] != "team=qa" {
+		t.Errorf("ParseHeaders = %v", got)
+	}
+	for _, bad := range []string{"novalue", "=x"} {
+		if _, err := ParseHeaders(bad); err == nil {
+			t.Errorf("ParseHeaders(%q): want error", bad)
+		}
+	}
+	if _, err := ParseHeaders(""); err != nil {
+		t.Errorf("ParseHeaders(\"\"): %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+// Package tracing — легкий трасувальник зі спанами у форматі OpenTelemetry: експорт через OTLP/HTTP (JSON)
+// або в пам'ять для тестів. Поки трасувальник не налаштовано (SetDefault), Start нічого не записує.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanContext ідентифікує спан у трасі.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid — чи це справжній спан (а не нульове значення без трасування).
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// TraceIDString — ID траси в hex, як його показують Jaeger/Tempo.
+func (sc SpanContext) TraceIDString() string {
+	return hex.EncodeToString(sc.TraceID[:])
+}
+
+// TraceParent — заголовок W3C traceparent, щоб сервіс на іншому боці міг продовжити трасу.
+func (sc SpanContext) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(sc.TraceID[:]), hex.EncodeToString(sc.SpanID[:]))
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext робить sc батьківським спаном для спанів, створених з повернутого контексту.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext повертає поточний спан контексту (нульовий, якщо трасування немає).
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// Attr — атрибут спану; Value — string, bool, int, int64 або float64 (інше записується як рядок).
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// String, Int, Int64 і Bool створюють атрибути спану.
+func String(key, v string) Attr      { return Attr{Key: key, Value: v} }
+func Int(key string, v int) Attr     { return Attr{Key: key, Value: int64(v)} }
+func Int64(key string, v int64) Attr { return Attr{Key: key, Value: v} }
+func Bool(key string, v bool) Attr   { return Attr{Key: key, Value: v} }
+
+// SpanKind — тип спану (значення як в OTLP).
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// SpanData — завершений спан, який отримує Exporter.
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	ParentSpanID  [8]byte
+	Kind          SpanKind
+	Start, End    time.Time
+	Attrs         []Attr
+	Error         bool
+	StatusMessage string
+}
+
+// Span — спан, що виконується. Усі методи безпечні для nil (коли трасування вимкнене).
+type Span struct {
+	tracer *Tracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// SetAttributes додає атрибути до спану.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attrs = append(s.data.Attrs, attrs...)
+}
+
+// RecordError позначає спан як помилковий (nil ігнорується).
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = true
+	s.data.StatusMessage = err.Error()
+}
+
+// SpanContext повертає ідентифікатори спану.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// End завершує спан і передає його на експорт; повторні виклики ігноруються.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+// Exporter відправляє завершені спани (OTLPExporter, InMemoryExporter).
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+const (
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 5 * time.Second
+)
+
+// Tracer створює спани і пакетами передає їх експортеру у фоні.
+type Tracer struct {
+	exporter Exporter
+	queue    chan SpanData
+	flushReq chan chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	dropped  atomic.Int64
+}
+
+// NewTracer створює трасувальник і запускає фоновий експорт; зупиняється через Shutdown.
+func NewTracer(exp Exporter) *Tracer {
+	t := &Tracer{
+		exporter: exp,
+		queue:    make(chan SpanData, queueSize),
+		flushReq: make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Start починає спан name, дочірній до спану з ctx (або корінь нової траси).
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{TraceID: parent.TraceID}
+	if !parent.IsValid() {
+		randomBytes(sc.TraceID[:])
+	}
+	randomBytes(sc.SpanID[:])
+	s := &Span{tracer: t, data: SpanData{
+		Name:         name,
+		SpanContext:  sc,
+		ParentSpanID: parent.SpanID,
+		Kind:         kind,
+		Start:        time.Now(),
+		Attrs:        attrs,
+	}}
+	return context.WithValue(ctx, spanContextKey{}, sc), s
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand на підтримуваних ОС не повертає помилок; ID з часу краще, ніж паніка.
+		n := time.Now().UnixNano()
+		for i := range b {
+			b[i] = byte(n >> (8 * (i % 8)))
+		}
+	}
+}
+
+// enqueue ставить спан у чергу експорту; якщо черга переповнена (експортер не встигає), спан відкидається.
+func (t *Tracer) enqueue(s SpanData) {
+	select {
+	case t.queue <- s:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	var batch []SpanData
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := t.exporter.Export(ctx, batch); err != nil {
+			slog.Warn("export spans", "spans", len(batch), "err", err)
+		}
+		cancel()
+		batch = nil
+		if n := t.dropped.Swap(0); n > 0 {
+			slog.Warn("tracing queue is full, spans dropped", "dropped", n)
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-t.queue:
+				batch = append(batch, s)
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case reply := <-t.flushReq:
+			drain()
+			flush()
+			close(reply)
+		case <-t.stop:
+			drain()
+			flush()
+			return
+		}
+	}
+}
+
+// ForceFlush експортує всі завершені спани і чекає на результат (або на завершення ctx).
+func (t *Tracer) ForceFlush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	reply := make(chan struct{})
+	select {
+	case t.flushReq <- reply:
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown експортує решту спанів і зупиняє фоновий експорт.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefault задає трасувальник для Start і StartClient (nil — вимкнути трасування).
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Start починає внутрішній спан трасувальником за замовчуванням.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return defaultTracer.Load().Start(ctx, name, KindInternal, attrs...)
+}
+
+// StartClient починає спан виклику зовнішнього сервісу (Ollama, Telegram API).
+func StartClient(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return defaultTracer.Load().Start(ctx, name, KindClient, attrs...)
+}
@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// useTracer робить трасувальник з InMemoryExporter трасувальником за замовчуванням на час тесту.
+func useTracer(t *testing.T) (*Tracer, *InMemoryExporter) {
+	t.Helper()
+	exp := NewInMemoryExporter()
+	tr := NewTracer(exp)
+	SetDefault(tr)
+	t.Cleanup(func() {
+		SetDefault(nil)
+		_ = tr.Shutdown(context.Background())
+	})
+	return tr, exp
+}
+
+func spanByName(t *testing.T, spans []SpanData, name string) SpanData {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %q in %+v", name, spans)
+	return SpanData{}
+}
+
+func TestSpanHierarchy(t *testing.T) {
+	tr, exp := useTracer(t)
+
+	// Як у боті: апдейт → завантаження файла → виклик Ollama всередині обробки апдейту.
+	updCtx, update := Start(context.Background(), "telegram.update", Int("update_id", 42))
+	_, download := StartClient(updCtx, "telegram.download_file")
+	download.SetAttributes(Int("bytes", 1024))
+	download.End()
+	_, generate := StartClient(updCtx, "ollama.generate")
+	generate.RecordError(errors.New("ollama http 500"))
+	generate.End()
+	update.End()
+
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := exp.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	root := spanByName(t, spans, "telegram.update")
+	dl := spanByName(t, spans, "telegram.download_file")
+	gen := spanByName(t, spans, "ollama.generate")
+
+	if root.ParentSpanID != [8]byte{} {
+		t.Error("root span has a parent")
+	}
+	if root.Kind != KindInternal || dl.Kind != KindClient || gen.Kind != KindClient {
+		t.Errorf("kinds = %d, %d, %d", root.Kind, dl.Kind, gen.Kind)
+	}
+	for _, child := range []SpanData{dl, gen} {
+		if child.SpanContext.TraceID != root.SpanContext.TraceID {
+			t.Errorf("%s: trace ID %s, want %s", child.Name, child.SpanContext.TraceIDString(), root.SpanContext.TraceIDString())
+		}
+		if child.ParentSpanID != root.SpanContext.SpanID {
+			t.Errorf("%s: parent is not the update span", child.Name)
+		}
+		if child.SpanContext.SpanID == root.SpanContext.SpanID {
+			t.Errorf("%s: reuses the parent span ID", child.Name)
+		}
+	}
+	if dl.Error || !gen.Error || gen.StatusMessage != "ollama http 500" {
+		t.Errorf("error status: download %v, generate %v %q", dl.Error, gen.Error, gen.StatusMessage)
+	}
+	if len(dl.Attrs) != 1 || dl.Attrs[0] != Int("bytes", 1024) {
+		t.Errorf("download attrs = %+v", dl.Attrs)
+	}
+	if root.End.Before(root.Start) {
+		t.Error("span ends before it starts")
+	}
+}
+
+func TestContextWithSpanContext(t *testing.T) {
+	tr, exp := useTracer(t)
+
+	ctx, update := Start(context.Background(), "telegram.update")
+	sc := update.SpanContext()
+	update.End()
+
+	// Відповіді надсилаються з окремого контексту, до якого спан апдейту додано явно.
+	_, send := StartClient(ContextWithSpanContext(context.Background(), sc), "telegram.sendMessage")
+	send.End()
+	if got := SpanContextFromContext(ctx); got != sc {
+		t.Errorf("SpanContextFromContext = %+v, want %+v", got, sc)
+	}
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := spanByName(t, exp.Spans(), "telegram.sendMessage"); s.ParentSpanID != sc.SpanID || s.SpanContext.TraceID != sc.TraceID {
+		t.Error("send span is not a child of the update span")
+	}
+	if want := "00-" + sc.TraceIDString() + "-"; len(sc.TraceParent()) != 55 || sc.TraceParent()[:36] != want {
+		t.Errorf("TraceParent = %q", sc.TraceParent())
+	}
+}
+
+func TestShutdownFlushes(t *testing.T) {
+	exp := NewInMemoryExporter()
+	tr := NewTracer(exp)
+	for i := 0; i < 10; i++ {
+		_, s := tr.Start(context.Background(), "span", KindInternal, Int("i", i))
+		s.End()
+	}
+	// Інтервал експорту — секунди, тож спани можуть з'явитись лише завдяки Shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tr.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exp.Spans()); n != 10 {
+		t.Errorf("exported %d spans after Shutdown, want 10", n)
+	}
+	if err := tr.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown: %v", err)
+	}
+	if err := tr.ForceFlush(ctx); err != nil {
+		t.Errorf("ForceFlush after Shutdown: %v", err)
+	}
+}
+
+func TestDisabledTracing(t *testing.T) {
+	SetDefault(nil)
+	ctx, s := Start(context.Background(), "noop")
+	if s != nil {
+		t.Fatal("Start without a tracer returned a span")
+	}
+	// Усі методи nil-спану — no-op.
+	s.SetAttributes(String("k", "v"))
+	s.RecordError(errors.New("x"))
+	s.End()
+	if SpanContextFromContext(ctx).IsValid() {
+		t.Error("context got a span context without a tracer")
+	}
+	if ContextWithSpanContext(ctx, SpanContext{}) != ctx {
+		t.Error("invalid span context changed the context")
+	}
+}
+
+func TestEndTwice(t *testing.T) {
+	tr, exp := useTracer(t)
+	_, s := Start(context.Background(), "once")
+	s.End()
+	s.End()
+	if err := tr.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exp.Spans()); n != 1 {
+		t.Errorf("got %d spans, want 1", n)
+	}
+	exp.Reset()
+	if n := len(exp.Spans()); n != 0 {
+		t.Errorf("after Reset got %d spans", n)
+	}
+}